@@ -10,17 +10,28 @@ func convertParamsFlags() []cli.Flag {
 		&cli.StringFlag{
 			Name:    "video-codec",
 			Aliases: []string{"vc"},
-			Usage:   "Video codec. Possible values: h264, hevc",
+			Usage:   "Video codec. Possible values: h264, hevc, av1",
 			Value:   "h264",
 		},
+		&cli.StringFlag{
+			Name:  "video-encoder",
+			Usage: "CPU encoder library for av1 codec. Possible values: libsvtav1 (default), libaom-av1",
+		},
 		&cli.StringFlag{
 			Name:    "hardware-acceleration",
 			Aliases: []string{"hwa"},
 			Usage: "Used hardware acceleration type. Possible values:\n" +
 				"                                               videotoolbox (for macs),\n" +
-				"                                               nvenc (for Nvidia GPUs).\n" +
+				"                                               nvenc (for Nvidia GPUs),\n" +
+				"                                               vaapi (for Intel & AMD GPUs on Linux),\n" +
+				"                                               qsv (Intel Quick Sync Video).\n" +
 				"                                               By default uses x264/x265 CPU encoders",
 		},
+		&cli.StringFlag{
+			Name:    "hardware-device",
+			Aliases: []string{"hwd"},
+			Usage:   "Hardware device for vaapi & qsv acceleration. Default: " + mediaConvert.DefaultVaapiDevice,
+		},
 		&cli.StringFlag{
 			Name:    "video-bitrate",
 			Aliases: []string{"vb"},
@@ -33,8 +44,142 @@ func convertParamsFlags() []cli.Flag {
 				"                                      Integer from 1 to 51 (30 is recommended). By default delegates choise to ffmpeg",
 		},
 		&cli.StringFlag{
-			Name:  "scale",
-			Usage: "Scaling. Possible values: 1/2 (half resolution), 1/4 (quarter resolution)",
+			Name: "target-size",
+			Usage: "Output file size (i.e. 8M, 50MB, 700K). Video bitrate is calculated from file duration & audio bitrate,\n" +
+				"                                  encoding is performed in two passes when encoder supports it. Overrides --video-bitrate & --video-quality",
+		},
+		&cli.Float64Flag{
+			Name: "target-vmaf",
+			Usage: "Choose --video-quality value for each file automatically by target VMAF score (i.e. 93).\n" +
+				"                                  Few short samples are encoded with different quality values before conversion.\n" +
+				"                                  Chosen value is written to conversion journal. Requires ffmpeg built with libvmaf",
+		},
+		&cli.BoolFlag{
+			Name:  "two-pass",
+			Usage: "Two-pass encoding with --video-bitrate (CPU encoders only: libx264, libx265, libaom-av1)",
+		},
+		&cli.StringFlag{
+			Name:    "audio-codec",
+			Aliases: []string{"ac"},
+			Value:   mediaConvert.CopyAudioCodecType,
+			Usage:   "Audio codec. Possible values: copy (keep original audio), aac, opus, mp3, ac3, flac",
+		},
+		&cli.StringFlag{
+			Name:    "audio-bitrate",
+			Aliases: []string{"ab"},
+			Usage:   "Audio bitrate. Requires --audio-codec. Examples: 192k, 320k",
+		},
+		&cli.IntFlag{
+			Name:  "audio-channels",
+			Usage: "Number of audio channels. Requires --audio-codec. Example: 2 (downmix to stereo)",
+		},
+		&cli.IntFlag{
+			Name:  "audio-sample-rate",
+			Usage: "Audio sample rate. Requires --audio-codec. Examples: 44100, 48000",
+		},
+		&cli.BoolFlag{
+			Name:  "drop-audio",
+			Usage: "Remove audio from output file",
+		},
+		&cli.BoolFlag{
+			Name:  "keep-all-streams",
+			Usage: "Keep all audio tracks, subtitles & data streams of input file",
+		},
+		&cli.StringSliceFlag{
+			Name: "audio-track",
+			Usage: "Keep only selected audio tracks. Value is an audio track index (starting from 0) or a language code.\n" +
+				"                                  Can be passed multiple times. Example: --audio-track 0 --audio-track eng",
+		},
+		&cli.BoolFlag{
+			Name:  "merge-audio",
+			Usage: "Mix audio tracks into one (i.e. game & microphone). Merged track is encoded with aac if --audio-codec is not set",
+		},
+		&cli.BoolFlag{
+			Name:  "copy-subtitles",
+			Usage: "Copy subtitle streams. Output container should support subtitle codec (mp4 supports only mov_text)",
+		},
+		&cli.BoolFlag{
+			Name:  "copy-data",
+			Usage: "Copy data streams (timecodes, telemetry, etc.)",
+		},
+		&cli.StringFlag{
+			Name:  "frame-rate",
+			Usage: "Target frame rate. Possible values: source (default), FPS value (i.e. 30, 29.97, 30000/1001)",
+		},
+		&cli.StringFlag{
+			Name: "frame-rate-mode",
+			Usage: "Frame rate mode. Possible values:\n" +
+				"                                    passthrough (default, keep original frame timestamps),\n" +
+				"                                    cfr (convert to constant frame rate),\n" +
+				"                                    auto (convert to constant frame rate only if variable frame rate is detected)",
+		},
+		&cli.StringFlag{
+			Name: "scale",
+			Usage: "Scaling. Possible values: 1/2 (half resolution), 1/4 (quarter resolution),\n" +
+				"                                  1280x720 (exact resolution), -2:1080 or 1280:-2 (preserve aspect ratio), 720p",
+		},
+		&cli.IntFlag{
+			Name:  "max-width",
+			Usage: "Downscale video with preserving aspect ratio if its width exceeds the value",
+		},
+		&cli.IntFlag{
+			Name:  "max-height",
+			Usage: "Downscale video with preserving aspect ratio if its height exceeds the value. Example: 1440",
+		},
+		&cli.StringFlag{
+			Name:  "already-encoded",
+			Value: mediaConvert.ReencodeAlreadyEncodedPolicy,
+			Usage: "What to do if input video is already encoded with target codec. Possible values:\n" +
+				"                                    reencode (convert as usual),\n" +
+				"                                    skip (do not convert file),\n" +
+				"                                    remux (copy video stream to output file without encoding)",
+		},
+		&cli.BoolFlag{
+			Name:  "compare-bitrate",
+			Usage: "Also apply --already-encoded policy if input video bitrate is not higher than --video-bitrate",
+		},
+		&cli.BoolFlag{
+			Name:  "keep-if-smaller",
+			Usage: "Keep output file only if it's smaller than input file",
+		},
+		&cli.Float64Flag{
+			Name:  "max-size-ratio",
+			Usage: "Keep output file only if its size is not bigger than input size multiplied by ratio (e.g. 0.9). Implies --keep-if-smaller",
+		},
+		&cli.StringFlag{
+			Name:  "larger-output",
+			Value: mediaConvert.DeleteLargerOutputPolicy,
+			Usage: "What to do with output file which is not smaller than input. Possible values:\n" +
+				"                                    delete (remove output file & mark task as skipped),\n" +
+				"                                    keep-input (replace output file with copy of input file)",
+		},
+		&cli.BoolFlag{
+			Name: "skip-metadata-copy",
+			Usage: "Do not copy container metadata & modification time from input file.\n" +
+				"                                    By default output file receives input tags, creation_time tag & modification time",
+		},
+		&cli.BoolFlag{
+			Name: "replace-original",
+			Usage: "Replace input files with converted ones. Output path argument is not required.\n" +
+				"                                    Converted file is checked (duration, streams, decodable ending) before replacement",
+		},
+		&cli.StringFlag{
+			Name:  "trash-path",
+			Usage: "Keep original files in this directory (--replace-original mode only)",
+		},
+		&cli.StringSliceFlag{
+			Name: "verify-quality",
+			Usage: "Compare converted video with original one. Possible values: vmaf, ssim, psnr.\n" +
+				"                                  Can be passed multiple times. VMAF requires ffmpeg built with libvmaf",
+		},
+		&cli.StringFlag{
+			Name:  "output-exists",
+			Value: mediaConvert.OverwriteOutputConflictPolicy,
+			Usage: "What to do if output file already exists. Possible values:\n" +
+				"                                    overwrite (replace existing file),\n" +
+				"                                    skip (do not convert file),\n" +
+				"                                    rename (add numeric suffix to output file name),\n" +
+				"                                    fail (report an error)",
 		},
 		&cli.StringFlag{
 			Name:  "preset",
@@ -65,7 +210,10 @@ func convertParamsFlags() []cli.Flag {
 				"\t- llhq\n" +
 				"\t- llhp\n" +
 				"\t- lossless\n" +
-				"\t- losslesshp\t",
+				"\t- losslesshp\n" +
+				"\t\n" +
+				"\tAV1 CPU encoders receive CPU-encoding values (mapped to numeric presets)\n" +
+				"\tor numeric value itself (0-13 for libsvtav1)\t",
 		},
 	}
 }
@@ -73,10 +221,43 @@ func convertParamsFlags() []cli.Flag {
 func convertParamsFromFlags(c *cli.Context) mediaConvert.Params {
 	return mediaConvert.Params{
 		HWAccel:      c.String("hwa"),
+		HWDevice:     c.String("hwd"),
 		VideoCodec:   c.String("video-codec"),
+		VideoEncoder: c.String("video-encoder"),
 		Preset:       c.String("preset"),
 		VideoBitRate: c.String("video-bitrate"),
 		VideoQuality: c.Int("video-quality"),
+		TargetSize:   c.String("target-size"),
+		TargetVMAF:   c.Float64("target-vmaf"),
+		TwoPass:      c.Bool("two-pass"),
 		Scale:        c.String("scale"),
+		MaxWidth:     c.Int("max-width"),
+		MaxHeight:    c.Int("max-height"),
+
+		FrameRate:     c.String("frame-rate"),
+		FrameRateMode: c.String("frame-rate-mode"),
+
+		AudioCodec:      c.String("audio-codec"),
+		AudioBitRate:    c.String("audio-bitrate"),
+		AudioChannels:   c.Int("audio-channels"),
+		AudioSampleRate: c.Int("audio-sample-rate"),
+		DropAudio:       c.Bool("drop-audio"),
+
+		KeepAllStreams: c.Bool("keep-all-streams"),
+		AudioTracks:    c.StringSlice("audio-track"),
+		MergeAudio:     c.Bool("merge-audio"),
+		CopySubtitles:  c.Bool("copy-subtitles"),
+		CopyData:       c.Bool("copy-data"),
+
+		OutputConflictPolicy: c.String("output-exists"),
+		VerifyQuality:        c.StringSlice("verify-quality"),
+		AlreadyEncodedPolicy: c.String("already-encoded"),
+		CompareBitRate:       c.Bool("compare-bitrate"),
+		KeepIfSmaller:        c.Bool("keep-if-smaller"),
+		MaxSizeRatio:         c.Float64("max-size-ratio"),
+		LargerOutputPolicy:   c.String("larger-output"),
+		SkipMetadataCopy:     c.Bool("skip-metadata-copy"),
+		ReplaceOriginal:      c.Bool("replace-original"),
+		TrashPath:            c.String("trash-path"),
 	}
 }
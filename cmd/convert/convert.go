@@ -1,7 +1,6 @@
 package convert
 
 import (
-	"context"
 	"fmt"
 
 	"github.com/wailorman/fftb/pkg/files"
@@ -10,6 +9,8 @@ import (
 	"github.com/pkg/errors"
 	"github.com/urfave/cli/v2"
 
+	"github.com/wailorman/fftb/cmd/filter"
+	"github.com/wailorman/fftb/cmd/tui"
 	mediaConvert "github.com/wailorman/fftb/pkg/media/convert"
 	"github.com/wailorman/fftb/pkg/media/minfo"
 )
@@ -33,34 +34,83 @@ func CliConfig() *cli.Command {
 			Aliases: []string{"R"},
 			Usage:   "Convert all video files in directory recursively",
 		},
+		&cli.BoolFlag{
+			Name:  "flatten",
+			Usage: "Put all converted files directly to output path instead of keeping input subdirectories (recursive mode only)",
+		},
 		&cli.BoolFlag{
 			Name:  "dry-run",
 			Usage: "Do not execute conversion and print yaml task config",
 		},
+		&cli.BoolFlag{
+			Name: "resolve-quality",
+			Usage: "Choose video quality by --target-vmaf during --dry-run and write it to yaml task config.\n" +
+				"                                  Samples of each file are encoded, so dry run takes longer",
+		},
 		&cli.StringFlag{
 			Name:  "config",
 			Usage: "Config file path (output from --dry-run option)",
 		},
+		&cli.StringFlag{
+			Name:  "probe-cache",
+			Usage: "Media info cache file path. Speeds up repeated scans of large directories",
+		},
+		&cli.IntFlag{
+			Name:  "probe-parallelism",
+			Usage: "Number of parallel ffprobe workers while scanning directory (recursive mode only). By default equals to number of CPUs",
+		},
+		&cli.BoolFlag{
+			Name: "resume",
+			Usage: "Skip tasks which were successfully converted in previous run.\n" +
+				"                                  Uses journal file from output path (" + mediaConvert.DefaultJournalFileName + ")",
+		},
+		&cli.StringFlag{
+			Name:  "progress-format",
+			Value: TextProgressFormat,
+			Usage: "Progress & events output format. Possible values:\n" +
+				"                                  text (log messages),\n" +
+				"                                  json (NDJSON events, see --progress-output)",
+		},
+		&cli.StringFlag{
+			Name:  "progress-output",
+			Usage: "File or FIFO path for json progress events. Stdout by default",
+		},
 	)
 
+	flags = append(flags, retryFlags()...)
+	flags = append(flags, filter.CliFlags()...)
+
 	return &cli.Command{
 		Name:    "convert",
 		Aliases: []string{"conv"},
 		Usage:   "Convert video",
 		UsageText: "single file mode: fftb convert [options] <input file> <output file>\n" +
 			"   recursive mode:   fftb convert [options] -R <input path> <output path>\n" +
+			"   in place mode:    fftb convert [options] --replace-original [-R] <input path>\n" +
 			"\n" +
 			"   If directory does not exists, it will create it for you.\n" +
-			"   WARNING: If file already exists, it will overwrite it",
+			"   WARNING: If file already exists, it will overwrite it (see --output-exists option)",
 
 		Flags: flags,
 
 		Action: func(c *cli.Context) error {
-			ctx := context.Background()
+			ctx := c.Context
+
+			var infoGetter *minfo.CachedInstance
 
-			infoGetter := minfo.New()
+			if c.String("probe-cache") != "" {
+				var err error
+				infoGetter, err = minfo.NewCachedWithFile(minfo.New(), files.NewFile(c.String("probe-cache")))
+
+				if err != nil {
+					return errors.Wrap(err, "Loading media info cache")
+				}
+			} else {
+				infoGetter = minfo.NewCached(minfo.New())
+			}
 
 			var progressChan chan mediaConvert.BatchProgressMessage
+			var resultChan chan mediaConvert.BatchResultMessage
 			var errChan chan mediaConvert.BatchErrorMessage
 
 			var batchTask mediaConvert.BatchTask
@@ -100,23 +150,64 @@ func CliConfig() *cli.Command {
 					},
 				}
 
-				if c.Bool("recursively") {
-					outputPath := c.Args().Get(1)
+				// journal is kept in output path only if resume is requested
+				if c.Bool("resume") {
+					batchTask.JournalPath = files.NewPath(outputPath).BuildFile(mediaConvert.DefaultJournalFileName).FullPath()
+				}
 
+				if c.Bool("recursively") {
 					batchTask, err = mediaConvert.BuildBatchTaskFromRecursive(mediaConvert.RecursiveTask{
 						Parallelism: c.Int("parallelism"),
 						InPath:      files.NewPath(inputPath),
 						OutPath:     files.NewPath(outputPath),
 						Params:      convertParamsFromFlags(c),
+						Flatten:     c.Bool("flatten"),
+						Filter:      filter.FilesFilterFromFlags(c),
+
+						ProbeParallelism: c.Int("probe-parallelism"),
+						Resume:           c.Bool("resume"),
 					}, infoGetter)
 
 					if err != nil {
 						return errors.Wrap(err, "Building recursive task")
 					}
+
+					if err = infoGetter.Save(); err != nil {
+						return errors.Wrap(err, "Saving media info cache")
+					}
 				}
 			}
 
+			if c.Bool("resume") {
+				batchTask.Resume = true
+			}
+
+			applyRetryFlags(c, &batchTask)
+
 			if c.Bool("dry-run") {
+				for i, task := range batchTask.Tasks {
+					if !mediaConvert.NeedsQualitySearch(task) {
+						continue
+					}
+
+					// without --resolve-quality dry run does not encode anything, so video quality is chosen during conversion
+					if !c.Bool("resolve-quality") {
+						logQualitySearchDeferred(task)
+						continue
+					}
+
+					videoQuality, err := mediaConvert.NewQualitySearch(ctx).Search(task)
+
+					if err != nil {
+						logQualitySearchError(task, err)
+						continue
+					}
+
+					// chosen value is kept in config, so conversion with --config is reproducible
+					batchTask.Tasks[i].Params.VideoQuality = videoQuality
+					logQualityChosen(task, videoQuality)
+				}
+
 				d, err := yaml.Marshal(&batchTask)
 				if err != nil {
 					return errors.Wrap(err, "Exporting to YAML")
@@ -126,24 +217,54 @@ func CliConfig() *cli.Command {
 				return nil
 			}
 
+			rep, err := buildReporter(c.String("progress-format"), c.String("progress-output"), tui.Enabled(c))
+
+			if err != nil {
+				return err
+			}
+
+			defer rep.Close()
+
 			converter := mediaConvert.NewBatchConverter(ctx, infoGetter)
 
-			progressChan, errChan = converter.Convert(batchTask)
+			startedChan, metadataChan := converter.TaskEvents()
+			progressChan, resultChan, errChan = converter.Convert(batchTask)
+
+			rep.batchStarted(converter.TasksCount())
+
+			summary := &batchSummary{}
 
 			for {
 				select {
+				case startedMessage, ok := <-startedChan:
+					if ok {
+						rep.taskStarted(startedMessage)
+					}
+
+				case metadataMessage, ok := <-metadataChan:
+					if ok {
+						rep.metadataReceived(metadataMessage)
+					}
+
 				case progressMessage, ok := <-progressChan:
 					if ok {
-						logProgress(progressMessage)
+						rep.progress(progressMessage)
+					}
+
+				case resultMessage, ok := <-resultChan:
+					if ok {
+						summary.addResult(resultMessage)
+						rep.result(resultMessage)
 					}
 
 				case failure, failed := <-errChan:
 					if !failed {
-						logDone()
+						rep.summary(summary, ctx.Err() != nil)
 						return nil
 					}
 
-					logError(failure)
+					summary.addError(failure)
+					rep.failure(failure)
 				}
 			}
 		},
@@ -1,10 +1,67 @@
 package convert
 
 import (
+	"path/filepath"
+	"time"
+
 	"github.com/pkg/errors"
 	"github.com/urfave/cli/v2"
+	mediaConvert "github.com/wailorman/fftb/pkg/media/convert"
+	"github.com/wailorman/fftb/pkg/media/ff"
 )
 
+func retryFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.DurationFlag{
+			Name:  "task-timeout",
+			Usage: "Maximum conversion time of each task attempt (i.e. 2h30m). Not limited by default",
+		},
+		&cli.DurationFlag{
+			Name:  "stall-timeout",
+			Usage: "Maximum time ffmpeg allowed to not report progress",
+			Value: ff.ProcessTimeout,
+		},
+		&cli.IntFlag{
+			Name:  "max-attempts",
+			Usage: "Maximum number of conversion attempts of failed task",
+			Value: 1,
+		},
+		&cli.DurationFlag{
+			Name:  "retry-backoff",
+			Usage: "Delay before second conversion attempt. Delay is doubled before each next attempt",
+			Value: 5 * time.Second,
+		},
+		&cli.BoolFlag{
+			Name:  "hwa-fallback",
+			Usage: "Convert task without hardware acceleration if attempt with hardware acceleration failed (requires --max-attempts > 1)",
+		},
+	}
+}
+
+// applyRetryFlags overrides batch timeouts & retry policy with flags.
+// Values from config file are kept if flags are not passed
+func applyRetryFlags(c *cli.Context, batchTask *mediaConvert.BatchTask) {
+	if c.IsSet("task-timeout") || batchTask.TaskTimeout == 0 {
+		batchTask.TaskTimeout = c.Duration("task-timeout")
+	}
+
+	if c.IsSet("stall-timeout") || batchTask.StallTimeout == 0 {
+		batchTask.StallTimeout = c.Duration("stall-timeout")
+	}
+
+	if c.IsSet("max-attempts") || batchTask.Retry.MaxAttempts == 0 {
+		batchTask.Retry.MaxAttempts = c.Int("max-attempts")
+	}
+
+	if c.IsSet("retry-backoff") || batchTask.Retry.Backoff == 0 {
+		batchTask.Retry.Backoff = c.Duration("retry-backoff")
+	}
+
+	if c.Bool("hwa-fallback") {
+		batchTask.Retry.HWAccelFallback = true
+	}
+}
+
 func pullInputPaths(c *cli.Context) (
 	inputPath string,
 	outputPath string,
@@ -19,6 +76,15 @@ func pullInputPaths(c *cli.Context) (
 
 	outputPath = c.Args().Get(1)
 
+	// converted files are placed to input files paths
+	if outputPath == "" && c.Bool("replace-original") {
+		if c.Bool("recursively") {
+			outputPath = inputPath
+		} else {
+			outputPath = filepath.Dir(inputPath)
+		}
+	}
+
 	if outputPath == "" {
 		err = errors.New("Missing output path second argument")
 		return
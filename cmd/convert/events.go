@@ -0,0 +1,249 @@
+package convert
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/wailorman/fftb/cmd/tui"
+	mediaConvert "github.com/wailorman/fftb/pkg/media/convert"
+	mediaUtils "github.com/wailorman/fftb/pkg/media/utils"
+)
+
+const (
+	batchStartedEvent     = "batch_started"
+	taskStartedEvent      = "task_started"
+	metadataReceivedEvent = "metadata_received"
+	progressEvent         = "progress"
+	taskDoneEvent         = "task_done"
+	taskFailedEvent       = "task_failed"
+	batchSummaryEvent     = "batch_summary"
+)
+
+// event is a line of NDJSON progress stream
+type event struct {
+	Type       string    `json:"type"`
+	Time       time.Time `json:"time"`
+	TaskID     string    `json:"task_id,omitempty"`
+	InputFile  string    `json:"input_file,omitempty"`
+	OutputFile string    `json:"output_file,omitempty"`
+	Attempt    int       `json:"attempt,omitempty"`
+
+	TasksCount int                `json:"tasks_count,omitempty"`
+	Metadata   *metadataEvent     `json:"metadata,omitempty"`
+	Progress   *progressEventData `json:"progress,omitempty"`
+	Batch      *batchEventData    `json:"batch,omitempty"`
+	Result     *resultEvent       `json:"result,omitempty"`
+	Failure    *failureEvent      `json:"failure,omitempty"`
+	Summary    *summaryEvent      `json:"summary,omitempty"`
+}
+
+type metadataEvent struct {
+	Duration   float64 `json:"duration"`
+	Size       int64   `json:"size"`
+	BitRate    int64   `json:"bit_rate"`
+	VideoCodec string  `json:"video_codec"`
+	Width      int     `json:"width"`
+	Height     int     `json:"height"`
+	FrameRate  string  `json:"frame_rate"`
+}
+
+type progressEventData struct {
+	Percent     float64 `json:"percent"`
+	FPS         float64 `json:"fps"`
+	Speed       string  `json:"speed"`
+	ETA         float64 `json:"eta_seconds,omitempty"`
+	BitRate     string  `json:"bit_rate"`
+	Frames      string  `json:"frames_processed"`
+	CurrentTime string  `json:"current_time"`
+}
+
+type batchEventData struct {
+	Percent          float64 `json:"percent"`
+	ProcessedSeconds float64 `json:"processed_seconds"`
+	TotalSeconds     float64 `json:"total_seconds"`
+	Speed            float64 `json:"speed"`
+	ETA              float64 `json:"eta_seconds,omitempty"`
+}
+
+type resultEvent struct {
+	OutputSize   int     `json:"output_size"`
+	InputSize    int     `json:"input_size"`
+	SavedBytes   int     `json:"saved_bytes"`
+	KeptInput    bool    `json:"kept_input,omitempty"`
+	VideoQuality int     `json:"video_quality,omitempty"`
+	VMAF         float64 `json:"vmaf,omitempty"`
+	SSIM         float64 `json:"ssim,omitempty"`
+	PSNR         float64 `json:"psnr,omitempty"`
+}
+
+type failureEvent struct {
+	Error       string `json:"error"`
+	Skipped     bool   `json:"skipped,omitempty"`
+	Interrupted bool   `json:"interrupted,omitempty"`
+	WillRetry   bool   `json:"will_retry,omitempty"`
+}
+
+type summaryEvent struct {
+	Finished    int  `json:"finished"`
+	Skipped     int  `json:"skipped"`
+	Failed      int  `json:"failed"`
+	Interrupted int  `json:"interrupted"`
+	SavedBytes  int  `json:"saved_bytes"`
+	Stopped     bool `json:"stopped,omitempty"`
+}
+
+// jsonReporter writes messages as NDJSON events
+type jsonReporter struct {
+	output    io.WriteCloser
+	encoder   *json.Encoder
+	startedAt map[string]time.Time
+	now       func() time.Time
+}
+
+func newJSONReporter(output io.WriteCloser) *jsonReporter {
+	return &jsonReporter{
+		output:    output,
+		encoder:   json.NewEncoder(output),
+		startedAt: make(map[string]time.Time),
+		now:       time.Now,
+	}
+}
+
+func (r *jsonReporter) write(e event) {
+	e.Time = r.now()
+
+	// progress stream is best effort, conversion should not fail because of closed pipe
+	r.encoder.Encode(e)
+}
+
+func (r *jsonReporter) taskEvent(eventType string, task mediaConvert.Task, attempt int) event {
+	return event{
+		Type:       eventType,
+		TaskID:     task.ID,
+		InputFile:  task.InFile,
+		OutputFile: task.OutFile,
+		Attempt:    attempt,
+	}
+}
+
+func (r *jsonReporter) batchStarted(tasksCount int) {
+	r.write(event{Type: batchStartedEvent, TasksCount: tasksCount})
+}
+
+func (r *jsonReporter) taskStarted(msg mediaConvert.BatchTaskStartedMessage) {
+	r.startedAt[msg.Task.ID] = r.now()
+	r.write(r.taskEvent(taskStartedEvent, msg.Task, msg.Attempt))
+}
+
+func (r *jsonReporter) metadataReceived(msg mediaConvert.MetadataReceivedBatchMessage) {
+	e := r.taskEvent(metadataReceivedEvent, msg.Task, 0)
+	format := msg.Metadata.Format
+
+	e.Metadata = &metadataEvent{
+		Duration:   msg.Metadata.DurationSeconds(),
+		VideoCodec: mediaUtils.GetVideoCodec(msg.Metadata),
+	}
+
+	e.Metadata.Size, _ = strconv.ParseInt(format.Size, 10, 64)
+	e.Metadata.BitRate, _ = strconv.ParseInt(format.BitRate, 10, 64)
+
+	if stream, ok := mediaUtils.FindVideoStream(msg.Metadata); ok {
+		e.Metadata.Width = stream.Width
+		e.Metadata.Height = stream.Height
+		e.Metadata.FrameRate = stream.AvgFrameRate
+	}
+
+	r.write(e)
+}
+
+func (r *jsonReporter) progress(msg mediaConvert.BatchProgressMessage) {
+	e := r.taskEvent(progressEvent, msg.Task, msg.Attempt)
+	progress := msg.Progress
+
+	e.Progress = &progressEventData{
+		Percent:     progress.Progress(),
+		FPS:         progress.FPS(),
+		Speed:       progress.Speed(),
+		BitRate:     progress.CurrentBitrate(),
+		Frames:      progress.FramesProcessed(),
+		CurrentTime: progress.CurrentTime(),
+	}
+
+	if startedAt, ok := r.startedAt[msg.Task.ID]; ok {
+		e.Progress.ETA = tui.EstimateRemaining(r.now().Sub(startedAt), progress.Progress()).Seconds()
+	}
+
+	e.Batch = &batchEventData{
+		Percent:          msg.Batch.Percent,
+		ProcessedSeconds: msg.Batch.ProcessedSeconds,
+		TotalSeconds:     msg.Batch.TotalSeconds,
+		Speed:            msg.Batch.Speed,
+		ETA:              msg.Batch.ETA.Seconds(),
+	}
+
+	r.write(e)
+}
+
+func (r *jsonReporter) result(msg mediaConvert.BatchResultMessage) {
+	e := r.taskEvent(taskDoneEvent, msg.Task, msg.Attempt)
+	e.OutputFile = msg.Result.OutFile
+
+	e.Result = &resultEvent{
+		OutputSize:   msg.Result.OutputSize,
+		InputSize:    msg.Result.InputSize,
+		SavedBytes:   msg.Result.SavedBytes(),
+		KeptInput:    msg.Result.KeptInput,
+		VideoQuality: msg.Result.VideoQuality,
+	}
+
+	if msg.Result.Quality != nil {
+		e.Result.VMAF = msg.Result.Quality.VMAF
+		e.Result.SSIM = msg.Result.Quality.SSIM
+		e.Result.PSNR = msg.Result.Quality.PSNR
+	}
+
+	delete(r.startedAt, msg.Task.ID)
+	r.write(e)
+}
+
+func (r *jsonReporter) failure(msg mediaConvert.BatchErrorMessage) {
+	e := r.taskEvent(taskFailedEvent, msg.Task, msg.Attempt)
+
+	e.Failure = &failureEvent{
+		Skipped:     msg.IsSkipped(),
+		Interrupted: msg.IsInterrupted(),
+		WillRetry:   msg.WillRetry,
+	}
+
+	if msg.Err != nil {
+		e.Failure.Error = msg.Err.Error()
+	}
+
+	delete(r.startedAt, msg.Task.ID)
+	r.write(e)
+}
+
+func (r *jsonReporter) summary(summary *batchSummary, interrupted bool) {
+	r.write(event{
+		Type: batchSummaryEvent,
+		Summary: &summaryEvent{
+			Finished:    len(summary.finished),
+			Skipped:     summary.skipped,
+			Failed:      summary.failed,
+			Interrupted: summary.interrupted,
+			SavedBytes:  summary.savedBytes,
+			Stopped:     interrupted,
+		},
+	})
+}
+
+func (r *jsonReporter) Close() error {
+	if r.output == os.Stdout {
+		return nil
+	}
+
+	return r.output.Close()
+}
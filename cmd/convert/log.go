@@ -1,11 +1,34 @@
 package convert
 
 import (
+	"time"
+
 	"github.com/sirupsen/logrus"
+	"github.com/wailorman/fftb/pkg/chtime"
 	"github.com/wailorman/fftb/pkg/ctxlog"
+	"github.com/wailorman/fftb/pkg/files"
 	mediaConvert "github.com/wailorman/fftb/pkg/media/convert"
 )
 
+func logBatchStarted(tasksCount int) {
+	ctxlog.Logger.WithField("tasks_count", tasksCount).
+		Info("Conversion started")
+}
+
+func logTaskStarted(msg mediaConvert.BatchTaskStartedMessage) {
+	ctxlog.Logger.WithField("task_id", msg.Task.ID).
+		WithField("task_input_file", msg.Task.InFile).
+		WithField("attempt", msg.Attempt).
+		Info("Task started")
+}
+
+func logMetadataReceived(msg mediaConvert.MetadataReceivedBatchMessage) {
+	ctxlog.Logger.WithField("task_id", msg.Task.ID).
+		WithField("duration", msg.Metadata.DurationSeconds()).
+		WithField("bit_rate", msg.Metadata.Format.BitRate).
+		Debug("Input metadata received")
+}
+
 func logProgress(msg mediaConvert.BatchProgressMessage) {
 	progress := msg.Progress
 
@@ -18,18 +41,148 @@ func logProgress(msg mediaConvert.BatchProgressMessage) {
 		"speed":            progress.Speed(),
 		"fps":              progress.FPS(),
 		"file_path":        progress.File().FullPath(),
+		"attempt":          msg.Attempt,
+		"batch_progress":   msg.Batch.Percent,
+		"batch_speed":      msg.Batch.Speed,
+		"batch_eta":        msg.Batch.ETA.Round(time.Second).String(),
 	}).Info("Converting progress")
 }
 
+func logResult(msg mediaConvert.BatchResultMessage) {
+	logger := ctxlog.Logger.
+		WithField("task_id", msg.Task.ID).
+		WithField("output_file", msg.Result.OutFile).
+		WithField("saved_bytes", msg.Result.SavedBytes())
+
+	if msg.Result.KeptInput {
+		logger = logger.WithField("kept_input", true)
+	}
+
+	if msg.Result.VideoQuality > 0 {
+		logger = logger.WithField("chosen_video_quality", msg.Result.VideoQuality)
+	}
+
+	if msg.Result.Quality != nil {
+		logger = logger.WithFields(logrus.Fields{
+			"vmaf": msg.Result.Quality.VMAF,
+			"ssim": msg.Result.Quality.SSIM,
+			"psnr": msg.Result.Quality.PSNR,
+		})
+	}
+
+	logger.Info("Task done")
+}
+
+func logQualitySearchDeferred(task mediaConvert.Task) {
+	ctxlog.Logger.WithField("task_input_file", task.InFile).
+		WithField("target_vmaf", task.Params.TargetVMAF).
+		Info("Video quality will be chosen by target VMAF during conversion")
+}
+
+func logQualityChosen(task mediaConvert.Task, videoQuality int) {
+	ctxlog.Logger.WithField("task_input_file", task.InFile).
+		WithField("target_vmaf", task.Params.TargetVMAF).
+		WithField("chosen_video_quality", videoQuality).
+		Info("Video quality is chosen")
+}
+
+func logQualitySearchError(task mediaConvert.Task, err error) {
+	ctxlog.Logger.WithField("task_input_file", task.InFile).
+		WithField("error", err.Error()).
+		Warn("Failed to choose video quality, it will be chosen during conversion")
+}
+
 func logError(errorMessage mediaConvert.BatchErrorMessage) {
+	if errorMessage.IsSkipped() {
+		ctxlog.Logger.WithField("reason", errorMessage.Err.Error()).
+			WithField("task_id", errorMessage.Task.ID).
+			WithField("task_input_file", errorMessage.Task.InFile).
+			Info("Task skipped")
+
+		return
+	}
+
+	if errorMessage.WillRetry {
+		ctxlog.Logger.WithField("error", errorMessage.Err.Error()).
+			WithField("task_id", errorMessage.Task.ID).
+			WithField("task_input_file", errorMessage.Task.InFile).
+			WithField("attempt", errorMessage.Attempt).
+			Warn("Attempt failed, retrying")
+
+		return
+	}
+
+	if errorMessage.IsInterrupted() {
+		ctxlog.Logger.WithField("task_id", errorMessage.Task.ID).
+			WithField("task_input_file", errorMessage.Task.InFile).
+			Info("Task interrupted")
+
+		return
+	}
+
 	if errorMessage.Err != nil {
 		ctxlog.Logger.WithField("error", errorMessage.Err.Error()).
 			WithField("task_id", errorMessage.Task.ID).
 			WithField("task_input_file", errorMessage.Task.InFile).
+			WithField("attempt", errorMessage.Attempt).
 			Warn("Error")
 	}
 }
 
-func logDone() {
-	ctxlog.Logger.Info("Conversion done")
+func logSummary(summary *batchSummary, interrupted bool) {
+	for _, msg := range summary.finished {
+		ctxlog.Logger.WithField("task_id", msg.Task.ID).
+			WithField("task_input_file", msg.Task.InFile).
+			WithField("output_file", msg.Result.OutFile).
+			Info("Finished task")
+	}
+
+	logger := ctxlog.Logger.WithFields(logrus.Fields{
+		"finished":          len(summary.finished),
+		"skipped":           summary.skipped,
+		"failed":            summary.failed,
+		"interrupted":       summary.interrupted,
+		"total_saved_bytes": summary.savedBytes,
+	})
+
+	if interrupted {
+		logger.Warn("Conversion interrupted")
+		return
+	}
+
+	logger.Info("Conversion done")
+}
+
+func logWatchStarted(inPath, outPath files.Pather) {
+	ctxlog.Logger.WithField("input_path", inPath.FullPath()).
+		WithField("output_path", outPath.FullPath()).
+		Info("Watching for new files")
+}
+
+func logWatchError(err error) {
+	ctxlog.Logger.WithField("error", err.Error()).
+		Warn("Watching error")
+}
+
+func logWatchedFileSkipped(file files.Filer, err error) {
+	ctxlog.Logger.WithField("reason", err.Error()).
+		WithField("file_path", file.FullPath()).
+		Debug("File skipped")
+}
+
+func logTimeSet(result chtime.Result) {
+	logger := ctxlog.Logger.WithField("file_path", result.File.FullPath()).
+		WithField("used_handler_name", result.UsedHandler)
+
+	if !result.Ok {
+		if result.Error != nil {
+			logger = logger.WithField("error", result.Error.Error())
+		}
+
+		logger.Debug("Time was not set")
+		return
+	}
+
+	logger.WithField("time", result.Time.Format(time.RFC3339)).
+		Info("Time was set")
 }
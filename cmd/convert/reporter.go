@@ -0,0 +1,87 @@
+package convert
+
+import (
+	"github.com/pkg/errors"
+	mediaConvert "github.com/wailorman/fftb/pkg/media/convert"
+	"github.com/wailorman/fftb/pkg/media/utils"
+)
+
+const (
+	// TextProgressFormat _
+	TextProgressFormat = "text"
+	// JSONProgressFormat _
+	JSONProgressFormat = "json"
+)
+
+// reporter presents batch conversion messages to user
+type reporter interface {
+	// batchStarted receives number of tasks which will be converted
+	batchStarted(tasksCount int)
+	taskStarted(msg mediaConvert.BatchTaskStartedMessage)
+	metadataReceived(msg mediaConvert.MetadataReceivedBatchMessage)
+	progress(msg mediaConvert.BatchProgressMessage)
+	result(msg mediaConvert.BatchResultMessage)
+	failure(msg mediaConvert.BatchErrorMessage)
+	summary(summary *batchSummary, interrupted bool)
+	Close() error
+}
+
+// buildReporter returns reporter for progress format.
+// Text progress is rendered as progress bars if interactive is true
+func buildReporter(format, outputPath string, interactive bool) (reporter, error) {
+	switch format {
+	case "", TextProgressFormat:
+		if interactive {
+			return newTTYReporter(), nil
+		}
+
+		return &textReporter{}, nil
+
+	case JSONProgressFormat:
+		output, err := utils.BuildOutputPipe(outputPath)
+
+		if err != nil {
+			return nil, errors.Wrap(err, "Building progress output")
+		}
+
+		return newJSONReporter(output), nil
+
+	default:
+		return nil, errors.Errorf("Unsupported progress format `%s`", format)
+	}
+}
+
+// textReporter writes messages to log
+type textReporter struct{}
+
+func (r *textReporter) batchStarted(tasksCount int) {
+	logBatchStarted(tasksCount)
+}
+
+func (r *textReporter) taskStarted(msg mediaConvert.BatchTaskStartedMessage) {
+	logTaskStarted(msg)
+}
+
+func (r *textReporter) metadataReceived(msg mediaConvert.MetadataReceivedBatchMessage) {
+	logMetadataReceived(msg)
+}
+
+func (r *textReporter) progress(msg mediaConvert.BatchProgressMessage) {
+	logProgress(msg)
+}
+
+func (r *textReporter) result(msg mediaConvert.BatchResultMessage) {
+	logResult(msg)
+}
+
+func (r *textReporter) failure(msg mediaConvert.BatchErrorMessage) {
+	logError(msg)
+}
+
+func (r *textReporter) summary(summary *batchSummary, interrupted bool) {
+	logSummary(summary, interrupted)
+}
+
+func (r *textReporter) Close() error {
+	return nil
+}
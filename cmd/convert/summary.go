@@ -0,0 +1,32 @@
+package convert
+
+import (
+	mediaConvert "github.com/wailorman/fftb/pkg/media/convert"
+)
+
+// batchSummary collects batch tasks outcomes to report them once conversion is finished or interrupted
+type batchSummary struct {
+	finished    []mediaConvert.BatchResultMessage
+	skipped     int
+	failed      int
+	interrupted int
+	savedBytes  int
+}
+
+func (s *batchSummary) addResult(msg mediaConvert.BatchResultMessage) {
+	s.finished = append(s.finished, msg)
+	s.savedBytes += msg.Result.SavedBytes()
+}
+
+func (s *batchSummary) addError(msg mediaConvert.BatchErrorMessage) {
+	switch {
+	case msg.WillRetry:
+		return
+	case msg.IsSkipped():
+		s.skipped++
+	case msg.IsInterrupted():
+		s.interrupted++
+	default:
+		s.failed++
+	}
+}
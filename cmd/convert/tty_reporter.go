@@ -0,0 +1,137 @@
+package convert
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/wailorman/fftb/cmd/tui"
+	mediaConvert "github.com/wailorman/fftb/pkg/media/convert"
+)
+
+// ttyReporter renders progress bar for each running task & batch progress bar.
+// Finished tasks are printed above progress bars
+type ttyReporter struct {
+	renderer *tui.Renderer
+
+	tasksCount int
+	doneCount  int
+	order      []string
+	bars       map[string]tui.Bar
+	startedAt  map[string]time.Time
+	batch      mediaConvert.BatchProgress
+}
+
+func newTTYReporter() *ttyReporter {
+	return &ttyReporter{
+		renderer:  tui.NewRenderer(os.Stderr),
+		bars:      make(map[string]tui.Bar),
+		startedAt: make(map[string]time.Time),
+	}
+}
+
+func (r *ttyReporter) batchStarted(tasksCount int) {
+	r.tasksCount = tasksCount
+	r.render()
+}
+
+func (r *ttyReporter) taskStarted(msg mediaConvert.BatchTaskStartedMessage) {
+	label := filepath.Base(msg.Task.InFile)
+
+	if msg.Attempt > 1 {
+		label = fmt.Sprintf("%s (attempt %d)", label, msg.Attempt)
+	}
+
+	if _, ok := r.bars[msg.Task.ID]; !ok {
+		r.order = append(r.order, msg.Task.ID)
+	}
+
+	r.bars[msg.Task.ID] = tui.Bar{Label: label}
+	r.startedAt[msg.Task.ID] = time.Now()
+	r.render()
+}
+
+func (r *ttyReporter) metadataReceived(msg mediaConvert.MetadataReceivedBatchMessage) {
+}
+
+func (r *ttyReporter) progress(msg mediaConvert.BatchProgressMessage) {
+	bar := r.bars[msg.Task.ID]
+	progress := msg.Progress
+
+	bar.Percent = progress.Progress()
+	bar.FPS = progress.FPS()
+	bar.Speed = progress.Speed()
+
+	if startedAt, ok := r.startedAt[msg.Task.ID]; ok {
+		bar.ETA = tui.EstimateRemaining(time.Since(startedAt), progress.Progress())
+	}
+
+	r.bars[msg.Task.ID] = bar
+	r.batch = msg.Batch
+	r.render()
+}
+
+func (r *ttyReporter) result(msg mediaConvert.BatchResultMessage) {
+	r.finishTask(msg.Task)
+	logResult(msg)
+}
+
+func (r *ttyReporter) failure(msg mediaConvert.BatchErrorMessage) {
+	if !msg.WillRetry {
+		r.finishTask(msg.Task)
+	}
+
+	logError(msg)
+}
+
+func (r *ttyReporter) summary(summary *batchSummary, interrupted bool) {
+	r.renderer.Stop()
+	logSummary(summary, interrupted)
+}
+
+func (r *ttyReporter) Close() error {
+	r.renderer.Stop()
+	return nil
+}
+
+func (r *ttyReporter) finishTask(task mediaConvert.Task) {
+	r.doneCount++
+
+	delete(r.bars, task.ID)
+	delete(r.startedAt, task.ID)
+
+	for i, id := range r.order {
+		if id == task.ID {
+			r.order = append(r.order[:i], r.order[i+1:]...)
+			break
+		}
+	}
+
+	r.render()
+}
+
+func (r *ttyReporter) render() {
+	bars := make([]tui.Bar, 0, len(r.order)+1)
+
+	for _, id := range r.order {
+		bars = append(bars, r.bars[id])
+	}
+
+	bars = append(bars, tui.Bar{
+		Label:   fmt.Sprintf("Total (%d/%d tasks)", r.doneCount, r.tasksCount),
+		Percent: r.batch.Percent,
+		Speed:   formatSpeed(r.batch.Speed),
+		ETA:     r.batch.ETA,
+	})
+
+	r.renderer.SetBars(bars)
+}
+
+func formatSpeed(speed float64) string {
+	if speed <= 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("%.2fx", speed)
+}
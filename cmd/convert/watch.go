@@ -0,0 +1,222 @@
+package convert
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+
+	"github.com/wailorman/fftb/cmd/filter"
+	"github.com/wailorman/fftb/pkg/chtime"
+	"github.com/wailorman/fftb/pkg/files"
+	mediaConvert "github.com/wailorman/fftb/pkg/media/convert"
+	"github.com/wailorman/fftb/pkg/media/minfo"
+	"github.com/wailorman/fftb/pkg/watch"
+)
+
+// WatchCliConfig _
+func WatchCliConfig() *cli.Command {
+	flags := convertParamsFlags()
+
+	flags = append(
+		flags,
+		&cli.IntFlag{
+			Name:    "parallelism",
+			Aliases: []string{"P"},
+			Usage:   "Number of parallel ffmpeg workers",
+			Value:   1,
+		},
+		&cli.DurationFlag{
+			Name:  "poll-interval",
+			Usage: "How often watched directory is checked for new files",
+			Value: watch.DefaultPollInterval,
+		},
+		&cli.DurationFlag{
+			Name: "stable-time",
+			Usage: "File is converted only if its size was not changed during this time\n" +
+				"                                  and it's not locked by other process (i.e. recording is finished)",
+			Value: watch.DefaultStableDuration,
+		},
+		&cli.BoolFlag{
+			Name:  "set-time",
+			Usage: "Set file modification time from its name before conversion (same as fftb etime)",
+		},
+		&cli.StringFlag{
+			Name:  "probe-cache",
+			Usage: "Media info cache file path",
+		},
+	)
+
+	flags = append(flags, retryFlags()...)
+	flags = append(flags, filter.CliFlags()...)
+
+	return &cli.Command{
+		Name:  "watch",
+		Usage: "Convert video files appearing in directory",
+		UsageText: "fftb watch [options] <input path> <output path>\n" +
+			"   in place mode: fftb watch [options] --replace-original <input path>\n" +
+			"\n" +
+			"   Watches input path recursively until interrupted. Converted files are remembered\n" +
+			"   in journal file (" + mediaConvert.DefaultJournalFileName + ") in output path, so they are not converted again after restart",
+
+		Flags: flags,
+
+		Action: func(c *cli.Context) error {
+			ctx := c.Context
+
+			inputPath := c.Args().Get(0)
+
+			if inputPath == "" {
+				return errors.New("Missing input path first argument")
+			}
+
+			outputPath := c.Args().Get(1)
+
+			if outputPath == "" && c.Bool("replace-original") {
+				outputPath = inputPath
+			}
+
+			if outputPath == "" {
+				return errors.New("Missing output path second argument")
+			}
+
+			inPath := files.NewPath(inputPath)
+			outPath := files.NewPath(outputPath)
+
+			// converted files would be picked up by watcher again
+			if inPath.Equal(outPath) && !c.Bool("replace-original") {
+				return errors.New("Output path should differ from input path (or use --replace-original)")
+			}
+
+			var infoGetter *minfo.CachedInstance
+
+			if c.String("probe-cache") != "" {
+				var err error
+				infoGetter, err = minfo.NewCachedWithFile(minfo.New(), files.NewFile(c.String("probe-cache")))
+
+				if err != nil {
+					return errors.Wrap(err, "Loading media info cache")
+				}
+			} else {
+				infoGetter = minfo.NewCached(minfo.New())
+			}
+
+			recursiveTask := mediaConvert.RecursiveTask{
+				InPath:  inPath,
+				OutPath: outPath,
+				Params:  convertParamsFromFlags(c),
+				Filter:  filter.FilesFilterFromFlags(c),
+			}
+
+			batchTask := mediaConvert.BatchTask{
+				Parallelism: c.Int("parallelism"),
+				JournalPath: outPath.BuildFile(mediaConvert.DefaultJournalFileName).FullPath(),
+				Resume:      true,
+			}
+
+			applyRetryFlags(c, &batchTask)
+
+			watcher := watch.New(inPath, recursiveTask.Filter)
+			watcher.PollInterval = c.Duration("poll-interval")
+			watcher.StableDuration = c.Duration("stable-time")
+
+			stableFiles, watchFailures := watcher.Watch(ctx)
+
+			converter := mediaConvert.NewBatchConverter(ctx, infoGetter)
+
+			startedChan, metadataChan := converter.TaskEvents()
+			progressChan, resultChan, errChan := converter.ConvertQueue(
+				batchTask,
+				buildWatchQueue(ctx, recursiveTask, stableFiles, c.Bool("set-time"), infoGetter),
+			)
+
+			logWatchStarted(inPath, outPath)
+
+			rep := &textReporter{}
+			summary := &batchSummary{}
+
+			for {
+				select {
+				case err, ok := <-watchFailures:
+					if !ok {
+						// watcher is stopped, but conversion of running tasks is still finishing
+						watchFailures = nil
+						break
+					}
+
+					logWatchError(err)
+
+				case startedMessage, ok := <-startedChan:
+					if ok {
+						rep.taskStarted(startedMessage)
+					}
+
+				case metadataMessage, ok := <-metadataChan:
+					if ok {
+						rep.metadataReceived(metadataMessage)
+					}
+
+				case progressMessage, ok := <-progressChan:
+					if ok {
+						rep.progress(progressMessage)
+					}
+
+				case resultMessage, ok := <-resultChan:
+					if ok {
+						summary.addResult(resultMessage)
+						rep.result(resultMessage)
+					}
+
+				case failure, failed := <-errChan:
+					if !failed {
+						if err := infoGetter.Save(); err != nil {
+							return errors.Wrap(err, "Saving media info cache")
+						}
+
+						rep.summary(summary, ctx.Err() != nil)
+						return nil
+					}
+
+					summary.addError(failure)
+					rep.failure(failure)
+				}
+			}
+		},
+	}
+}
+
+// buildWatchQueue converts stable files to conversion tasks. Queue is closed when watcher is stopped
+func buildWatchQueue(
+	ctx context.Context,
+	recursiveTask mediaConvert.RecursiveTask,
+	stableFiles chan files.Filer,
+	setTime bool,
+	infoGetter minfo.Getter,
+) chan mediaConvert.Task {
+	queue := make(chan mediaConvert.Task)
+
+	go func() {
+		defer close(queue)
+
+		for file := range stableFiles {
+			task, err := mediaConvert.BuildWatchTask(recursiveTask, file, infoGetter)
+
+			if err != nil {
+				logWatchedFileSkipped(file, err)
+				continue
+			}
+
+			if setTime {
+				logTimeSet(chtime.New(file, infoGetter).Perform())
+			}
+
+			select {
+			case queue <- task:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return queue
+}
@@ -8,9 +8,11 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
 
+	"github.com/wailorman/fftb/cmd/filter"
 	"github.com/wailorman/fftb/pkg/chtime"
 	"github.com/wailorman/fftb/pkg/ctxlog"
 	"github.com/wailorman/fftb/pkg/files"
+	"github.com/wailorman/fftb/pkg/media/minfo"
 )
 
 // CliConfig _
@@ -27,13 +29,13 @@ func CliConfig() *cli.Command {
 			"   Far Cry New Dawn 2020.02.12 - 23.03.10.00.DVR.mp4\n" +
 			"   Far Cry New Dawn 2020.02.12 - 23.03.10.00.mp4\n" +
 			"   2016_05_20_15_31_51-ses.mp4\n",
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			&cli.BoolFlag{
 				Name:    "recursively",
 				Aliases: []string{"R"},
 				Usage:   "Go through all files recursively",
 			},
-		},
+		}, filter.CliFlags()...),
 
 		Action: func(c *cli.Context) error {
 			pwd, err := os.Getwd()
@@ -48,15 +50,17 @@ func CliConfig() *cli.Command {
 				return errors.New("Missing path argument")
 			}
 
-			return setTimes(pwd, path, c.Bool("recursively"))
+			return setTimes(pwd, path, c.Bool("recursively"), filter.FilesFilterFromFlags(c))
 		},
 	}
 }
 
-func setTimes(pwd, path string, recursively bool) error {
+func setTimes(pwd, path string, recursively bool, filesFilter files.FilesFilter) error {
+	infoGetter := minfo.NewCached(minfo.New())
+
 	if recursively {
 		path := files.NewPath(path)
-		resChan, done := chtime.NewRecursive(path).Perform()
+		resChan, done := chtime.NewRecursive(path, filesFilter, infoGetter).Perform()
 
 		for {
 			select {
@@ -68,7 +72,7 @@ func setTimes(pwd, path string, recursively bool) error {
 		}
 	} else {
 		file := files.NewFile(path)
-		res := chtime.New(file).Perform()
+		res := chtime.New(file, infoGetter).Perform()
 
 		logResults(res)
 	}
@@ -0,0 +1,45 @@
+package filter
+
+import (
+	"github.com/urfave/cli/v2"
+	"github.com/wailorman/fftb/pkg/files"
+)
+
+// CliFlags returns flags for filtering files in recursive mode
+func CliFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringSliceFlag{
+			Name: "include",
+			Usage: "Process only files matching glob pattern (recursive mode only). Can be passed multiple times.\n" +
+				"                                  Patterns with slash are matched against relative path, others against file name.\n" +
+				"                                  Example: --include '*.DVR.mp4'",
+		},
+		&cli.StringSliceFlag{
+			Name:  "exclude",
+			Usage: "Skip files & directories matching glob pattern (recursive mode only). Can be passed multiple times",
+		},
+		&cli.StringSliceFlag{
+			Name:  "ext",
+			Usage: "Process only files with extension (recursive mode only). Can be passed multiple times. Example: --ext mp4 --ext mov",
+		},
+		&cli.IntFlag{
+			Name:  "max-depth",
+			Usage: "Maximum directories nesting level (recursive mode only). 1 means only files from input path. By default is unlimited",
+		},
+		&cli.BoolFlag{
+			Name:  "skip-hidden",
+			Usage: "Skip hidden files & directories (which names start with dot) in recursive mode",
+		},
+	}
+}
+
+// FilesFilterFromFlags _
+func FilesFilterFromFlags(c *cli.Context) files.FilesFilter {
+	return files.FilesFilter{
+		Include:    c.StringSlice("include"),
+		Exclude:    c.StringSlice("exclude"),
+		Extensions: c.StringSlice("ext"),
+		MaxDepth:   c.Int("max-depth"),
+		SkipHidden: c.Bool("skip-hidden"),
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/wailorman/fftb/cmd/etime"
 	"github.com/wailorman/fftb/cmd/log"
 	"github.com/wailorman/fftb/cmd/minfo"
+	"github.com/wailorman/fftb/cmd/quality"
+	"github.com/wailorman/fftb/cmd/serve"
 	"github.com/wailorman/fftb/cmd/split"
 	"github.com/wailorman/fftb/pkg/ctxlog"
 
@@ -57,13 +59,22 @@ func cliApp() {
 			etime.CliConfig(),
 			split.CliConfig(),
 			convert.CliConfig(),
+			convert.WatchCliConfig(),
 			minfo.CliConfig(),
+			quality.CliConfig(),
+			serve.CliConfig(),
 		},
 	}
 
-	err := app.Run(os.Args)
+	ctx := interruptibleContext()
+
+	err := app.RunContext(ctx, os.Args)
 
 	if err != nil {
 		ctxlog.Logger.Fatal(err)
 	}
+
+	if ctx.Err() != nil {
+		os.Exit(exitCodeInterrupted)
+	}
 }
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/wailorman/fftb/pkg/ctxlog"
+	"github.com/wailorman/fftb/pkg/goffmpeg/transcoder"
+)
+
+// exitCodeInterrupted is a conventional exit code of process interrupted by SIGINT
+const exitCodeInterrupted = 130
+
+// interruptibleContext returns context which is cancelled on first SIGINT/SIGTERM,
+// so commands can stop ffmpeg gracefully & clean up temporary files.
+// Second signal kills all ffmpeg processes & exits immediately
+func interruptibleContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		sig := <-signals
+
+		ctxlog.Logger.WithField("signal", sig.String()).
+			Warn("Stopping... Send signal again to exit immediately")
+
+		cancel()
+
+		sig = <-signals
+
+		ctxlog.Logger.WithField("signal", sig.String()).
+			Warn("Forced exit")
+
+		transcoder.KillAll()
+		os.Exit(exitCodeInterrupted)
+	}()
+
+	return ctx
+}
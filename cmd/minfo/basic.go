@@ -7,10 +7,17 @@ import (
 	"github.com/pkg/errors"
 	"github.com/urfave/cli/v2"
 	"github.com/wailorman/fftb/pkg/files"
+	ffmpegModels "github.com/wailorman/fftb/pkg/goffmpeg/models"
 	"github.com/wailorman/fftb/pkg/media/minfo"
 	"github.com/wailorman/fftb/pkg/media/utils"
 )
 
+// basicInfo is ffprobe's metadata with detected video properties
+type basicInfo struct {
+	ffmpegModels.Metadata
+	VariableFrameRate bool `json:"variable_frame_rate"`
+}
+
 var basicSubcommand = &cli.Command{
 	Name: "basic",
 	Flags: []cli.Flag{
@@ -41,7 +48,14 @@ var basicSubcommand = &cli.Command{
 
 		metadata, err := infoGetter.GetMediaInfo(inputFile)
 
-		jsonBytes, err := json.Marshal(metadata)
+		if err != nil {
+			return errors.Wrap(err, "Getting media info")
+		}
+
+		jsonBytes, err := json.Marshal(basicInfo{
+			Metadata:          metadata,
+			VariableFrameRate: utils.IsVariableFrameRate(metadata),
+		})
 
 		if err != nil {
 			return errors.Wrap(err, "Marshaling json")
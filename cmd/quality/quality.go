@@ -0,0 +1,89 @@
+package quality
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+
+	"github.com/wailorman/fftb/pkg/files"
+	"github.com/wailorman/fftb/pkg/media/quality"
+	"github.com/wailorman/fftb/pkg/media/utils"
+)
+
+// CliConfig _
+func CliConfig() *cli.Command {
+	return &cli.Command{
+		Name:      "quality",
+		Usage:     "Compare converted video with original one & return quality scores JSON object",
+		UsageText: "fftb quality [options] <reference (original) file> <distorted (converted) file>",
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:  "metric",
+				Value: cli.NewStringSlice(quality.DefaultMetrics()...),
+				Usage: "Quality metric. Possible values: vmaf, ssim, psnr. Can be passed multiple times.\n" +
+					"                               VMAF requires ffmpeg built with libvmaf",
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+			},
+		},
+		Action: func(c *cli.Context) (err error) {
+			ctx := c.Context
+
+			referenceFilePath := c.Args().Get(0)
+			distortedFilePath := c.Args().Get(1)
+
+			if referenceFilePath == "" || distortedFilePath == "" {
+				return errors.New("Missing reference or distorted file path argument")
+			}
+
+			referenceFile := files.NewFile(referenceFilePath)
+			distortedFile := files.NewFile(distortedFilePath)
+
+			for _, file := range []files.Filer{referenceFile, distortedFile} {
+				if !file.IsExist() {
+					return fmt.Errorf("Input file does not exists: %s", file.FullPath())
+				}
+			}
+
+			measurer, err := quality.New(ctx, c.StringSlice("metric"))
+
+			if err != nil {
+				return errors.Wrap(err, "Building quality measurer")
+			}
+
+			outputWriter, err := utils.BuildOutputPipe(c.String("output"))
+
+			if err != nil {
+				return errors.Wrap(err, "Building output pipe")
+			}
+
+			defer func() {
+				if closeErr := outputWriter.Close(); closeErr != nil && err == nil {
+					err = errors.Wrap(closeErr, "Closing output")
+				}
+			}()
+
+			scores, err := measurer.Measure(referenceFile, distortedFile)
+
+			if err != nil {
+				return errors.Wrap(err, "Measuring quality")
+			}
+
+			jsonBytes, err := json.Marshal(scores)
+
+			if err != nil {
+				return errors.Wrap(err, "Marshaling json")
+			}
+
+			if _, err = outputWriter.WriteString(string(jsonBytes) + "\n"); err != nil {
+				return errors.Wrap(err, "Writing output")
+			}
+
+			return nil
+		},
+	}
+}
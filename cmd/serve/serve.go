@@ -0,0 +1,126 @@
+package serve
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+
+	"github.com/wailorman/fftb/pkg/ctxlog"
+	"github.com/wailorman/fftb/pkg/files"
+	"github.com/wailorman/fftb/pkg/jobs"
+	"github.com/wailorman/fftb/pkg/media/minfo"
+)
+
+// shutdownTimeout limits time of waiting for HTTP requests to finish
+const shutdownTimeout = 5 * time.Second
+
+// CliConfig _
+func CliConfig() *cli.Command {
+	return &cli.Command{
+		Name:  "serve",
+		Usage: "Run HTTP API for submitting & monitoring conversion jobs",
+		UsageText: "fftb serve [options]\n" +
+			"\n" +
+			"   WARNING: API has no authentication, do not expose it to untrusted networks",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "listen",
+				Usage: "Address to listen. Use :8070 to accept connections from other machines",
+				Value: "127.0.0.1:8070",
+			},
+			&cli.StringFlag{
+				Name:  "state-dir",
+				Usage: "Directory for jobs & their journals. Default: <user config dir>/fftb",
+			},
+			&cli.IntFlag{
+				Name:  "jobs",
+				Usage: "Number of jobs converted at the same time. Each job uses its own parallelism value",
+				Value: 1,
+			},
+		},
+
+		Action: func(c *cli.Context) error {
+			ctx := c.Context
+
+			stateDir := c.String("state-dir")
+
+			if stateDir == "" {
+				configDir, err := os.UserConfigDir()
+
+				if err != nil {
+					return errors.Wrap(err, "Getting user config directory")
+				}
+
+				stateDir = filepath.Join(configDir, "fftb")
+			}
+
+			manager := jobs.NewManager(files.NewPath(stateDir), minfo.NewCached(minfo.New()))
+
+			if err := manager.Load(); err != nil {
+				return err
+			}
+
+			server := &http.Server{
+				Addr:    c.String("listen"),
+				Handler: jobs.NewHandler(ctx, manager),
+			}
+
+			// port is bound before restored jobs are started, so busy address doesn't leave ffmpeg running
+			listener, err := net.Listen("tcp", server.Addr)
+
+			if err != nil {
+				return errors.Wrap(err, "Starting HTTP server")
+			}
+
+			managerCtx, managerCancel := context.WithCancel(ctx)
+			defer managerCancel()
+
+			manager.Start(managerCtx, c.Int("jobs"))
+
+			serverErr := make(chan error, 1)
+
+			go func() {
+				serverErr <- server.Serve(listener)
+			}()
+
+			logListening(listener.Addr().String(), stateDir)
+
+			select {
+			case err := <-serverErr:
+				managerCancel()
+				manager.Wait()
+
+				return errors.Wrap(err, "Serving HTTP")
+			case <-ctx.Done():
+			}
+
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+			defer cancel()
+
+			err = server.Shutdown(shutdownCtx)
+
+			// running jobs are interrupted & will be resumed after restart
+			manager.Wait()
+
+			if err != nil {
+				return errors.Wrap(err, "Stopping HTTP server")
+			}
+
+			ctxlog.Logger.Info("Server stopped")
+
+			return nil
+		},
+	}
+}
+
+func logListening(addr, stateDir string) {
+	ctxlog.Logger.WithField("address", addr).
+		WithField("state_dir", stateDir).
+		Info("Listening")
+}
@@ -3,11 +3,14 @@ package split
 import (
 	"context"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
 
+	"github.com/wailorman/fftb/cmd/tui"
 	"github.com/wailorman/fftb/pkg/ctxlog"
 	"github.com/wailorman/fftb/pkg/files"
 	"github.com/wailorman/fftb/pkg/media/chunk"
@@ -34,7 +37,7 @@ func CliConfig() *cli.Command {
 		},
 
 		Action: func(c *cli.Context) error {
-			ctx := context.Background()
+			ctx := c.Context
 			pwd, err := os.Getwd()
 
 			if err != nil {
@@ -53,12 +56,12 @@ func CliConfig() *cli.Command {
 				return errors.New("Missing output path argument")
 			}
 
-			return splitToChunks(ctx, pwd, inputFilePath, c.Int("chunk-size"), outputPath)
+			return splitToChunks(ctx, pwd, inputFilePath, c.Int("chunk-size"), outputPath, tui.Enabled(c))
 		},
 	}
 }
 
-func splitToChunks(ctx context.Context, pwd, path string, chunkSize int, relativeChunksPath string) error {
+func splitToChunks(ctx context.Context, pwd, path string, chunkSize int, relativeChunksPath string, interactive bool) error {
 	mainFile := files.NewFile(path)
 	outPath := files.NewPath(relativeChunksPath)
 
@@ -78,13 +81,36 @@ func splitToChunks(ctx context.Context, pwd, path string, chunkSize int, relativ
 		SegmentDurationSec: chunkSize,
 	})
 
+	var renderer *tui.Renderer
+
+	if interactive {
+		renderer = tui.NewRenderer(os.Stderr)
+		defer renderer.Stop()
+	}
+
+	startedAt := time.Now()
 	cProgress, cFailures := chunker.Start()
 
 	for {
 		select {
 		case progressMsg := <-cProgress:
-			logProgress(progressMsg)
+			if renderer == nil {
+				logProgress(progressMsg)
+				break
+			}
+
+			renderer.SetBars([]tui.Bar{{
+				Label:   filepath.Base(mainFile.FullPath()),
+				Percent: progressMsg.Progress(),
+				FPS:     progressMsg.FPS(),
+				Speed:   progressMsg.Speed(),
+				ETA:     tui.EstimateRemaining(time.Since(startedAt), progressMsg.Progress()),
+			}})
 		case failure, failed := <-cFailures:
+			if renderer != nil {
+				renderer.Stop()
+			}
+
 			if !failed {
 				logDone()
 				return nil
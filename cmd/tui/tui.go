@@ -0,0 +1,182 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-isatty"
+	"github.com/urfave/cli/v2"
+	"github.com/wailorman/fftb/pkg/ctxlog"
+)
+
+// maxVerbosity is a highest verbosity level with progress bars.
+// Trace logs are too noisy, so they are printed as is
+const maxVerbosity = 5
+
+const (
+	labelWidth = 32
+	barWidth   = 30
+)
+
+// Enabled returns true if progress bars can be rendered: stderr is a terminal & verbosity is not too high.
+// Bars are rendered to stderr, so stdout can still be piped
+func Enabled(c *cli.Context) bool {
+	return c.Int("verbosity") <= maxVerbosity && isatty.IsTerminal(os.Stderr.Fd())
+}
+
+// Bar is a single progress bar line
+type Bar struct {
+	Label   string
+	Percent float64
+	FPS     float64
+	Speed   string
+	ETA     time.Duration
+}
+
+// Renderer redraws progress bars at the bottom of terminal.
+// Log messages are printed above progress bars
+type Renderer struct {
+	mutex         sync.Mutex
+	out           io.Writer
+	bars          []Bar
+	renderedLines int
+
+	// logOutput is log writer replaced by renderer, nil if log is written elsewhere
+	logOutput io.Writer
+}
+
+// NewRenderer creates renderer. If log is written to the same output,
+// it's redirected to renderer until Stop() call, so log messages don't break progress bars
+func NewRenderer(out io.Writer) *Renderer {
+	r := &Renderer{out: out}
+
+	if logOutput := ctxlog.Output(); logOutput == out {
+		r.logOutput = logOutput
+		ctxlog.SetOutput(r)
+	}
+
+	return r
+}
+
+// SetBars replaces progress bars & redraws them
+func (r *Renderer) SetBars(bars []Bar) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.bars = bars
+
+	r.clear()
+	r.draw()
+}
+
+// Write prints log message above progress bars
+func (r *Renderer) Write(p []byte) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.clear()
+	n, err := r.out.Write(p)
+	r.draw()
+
+	return n, err
+}
+
+// Println prints line above progress bars
+func (r *Renderer) Println(line string) {
+	r.Write([]byte(line + "\n"))
+}
+
+// Stop removes progress bars & restores log output. Can be called multiple times
+func (r *Renderer) Stop() {
+	r.mutex.Lock()
+	r.clear()
+	r.bars = nil
+	logOutput := r.logOutput
+	r.logOutput = nil
+	r.mutex.Unlock()
+
+	// log output is restored without renderer lock: logger can be writing to renderer right now
+	if logOutput != nil {
+		ctxlog.SetOutput(logOutput)
+	}
+}
+
+func (r *Renderer) clear() {
+	if r.renderedLines > 0 {
+		// move cursor up & clear everything below
+		fmt.Fprintf(r.out, "\x1b[%dA\x1b[J", r.renderedLines)
+	}
+
+	r.renderedLines = 0
+}
+
+func (r *Renderer) draw() {
+	for _, bar := range r.bars {
+		fmt.Fprintln(r.out, FormatBar(bar))
+	}
+
+	r.renderedLines = len(r.bars)
+}
+
+// FormatBar returns progress bar line:
+// label [#########-----------]  42.5%  120 fps  2.01x  ETA 1m2s
+func FormatBar(bar Bar) string {
+	percent := bar.Percent
+
+	if percent < 0 {
+		percent = 0
+	}
+
+	if percent > 100 {
+		percent = 100
+	}
+
+	filled := int(percent / 100 * barWidth)
+
+	line := fmt.Sprintf(
+		"%-*s [%s%s] %5.1f%%",
+		labelWidth,
+		truncateLabel(bar.Label),
+		strings.Repeat("#", filled),
+		strings.Repeat("-", barWidth-filled),
+		percent,
+	)
+
+	if bar.FPS > 0 {
+		line += fmt.Sprintf("  %.0f fps", bar.FPS)
+	}
+
+	if bar.Speed != "" {
+		line += "  " + bar.Speed
+	}
+
+	if bar.ETA > 0 {
+		line += "  ETA " + bar.ETA.Round(time.Second).String()
+	}
+
+	return line
+}
+
+// truncateLabel keeps the end of label, because file names usually differ by suffix
+func truncateLabel(label string) string {
+	runes := []rune(label)
+
+	if len(runes) <= labelWidth {
+		return label
+	}
+
+	return "…" + string(runes[len(runes)-labelWidth+1:])
+}
+
+// EstimateRemaining returns approximate time left by elapsed time & progress percent
+func EstimateRemaining(elapsed time.Duration, percent float64) time.Duration {
+	if percent <= 0 || percent >= 100 {
+		return 0
+	}
+
+	return time.Duration(float64(elapsed) * (100 - percent) / percent)
+}
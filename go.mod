@@ -4,6 +4,7 @@ go 1.13
 
 require (
 	github.com/mattn/go-colorable v0.1.8 // indirect
+	github.com/mattn/go-isatty v0.0.12
 	github.com/mgutz/ansi v0.0.0-20200706080929-d51e80ef957d // indirect
 	github.com/onsi/ginkgo v1.15.0 // indirect
 	github.com/pkg/errors v0.9.1
@@ -5,17 +5,20 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/wailorman/fftb/pkg/files"
+	"github.com/wailorman/fftb/pkg/media/minfo"
 )
 
 // Instance _
 type Instance struct {
-	file files.Filer
+	file       files.Filer
+	infoGetter minfo.Getter
 }
 
 // New _
-func New(file files.Filer) *Instance {
+func New(file files.Filer, infoGetter minfo.Getter) *Instance {
 	return &Instance{
-		file: file,
+		file:       file,
+		infoGetter: infoGetter,
 	}
 }
 
@@ -45,7 +48,7 @@ func newResult(ok bool, time time.Time, file files.Filer, usedHandler string, er
 
 // Perform _
 func (t *Instance) Perform() Result {
-	extractedTime, usedHandler, err := ExtractTime(t.file)
+	extractedTime, usedHandler, err := ExtractTime(t.file, t.infoGetter)
 
 	if err != nil {
 		return newResult(false, extractedTime, t.file, usedHandler, err)
@@ -76,13 +79,17 @@ func (t *Instance) GetTimecode() (time.Time, error) {
 
 // RecursiveInstance _
 type RecursiveInstance struct {
-	path files.Pather
+	path       files.Pather
+	filter     files.FilesFilter
+	infoGetter minfo.Getter
 }
 
 // NewRecursive _
-func NewRecursive(path files.Pather) *RecursiveInstance {
+func NewRecursive(path files.Pather, filter files.FilesFilter, infoGetter minfo.Getter) *RecursiveInstance {
 	return &RecursiveInstance{
-		path: path,
+		path:       path,
+		filter:     filter,
+		infoGetter: infoGetter,
 	}
 }
 
@@ -91,7 +98,7 @@ func (rt *RecursiveInstance) Perform() (chan Result, chan bool) {
 	results := make(chan Result, 0)
 	done := make(chan bool, 1)
 
-	files, err := rt.path.Files()
+	files, err := rt.path.FilesWithFilter(rt.filter)
 
 	if err != nil {
 		panic(errors.Wrap(err, "Getting files from path"))
@@ -99,7 +106,7 @@ func (rt *RecursiveInstance) Perform() (chan Result, chan bool) {
 
 	go func() {
 		for _, file := range files {
-			results <- New(file).Perform()
+			results <- New(file, rt.infoGetter).Perform()
 		}
 
 		done <- true
@@ -22,9 +22,7 @@ type ExtractTimeHandler interface {
 }
 
 // ExtractTime _
-func ExtractTime(file files.Filer) (time.Time, string, error) {
-	infoGetter := minfo.New()
-
+func ExtractTime(file files.Filer, infoGetter minfo.Getter) (time.Time, string, error) {
 	patterns := []ExtractTimeHandler{
 		handlers.NewGeforceDVR(mediaDuration.NewCalculator(infoGetter)),
 		handlers.NewGeforceFull(),
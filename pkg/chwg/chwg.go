@@ -11,31 +11,43 @@ func New() *ChannelledWaitGroup {
 
 // ChannelledWaitGroup _
 type ChannelledWaitGroup struct {
+	mutex   sync.Mutex
 	counter int
 	wg      *sync.WaitGroup
 }
 
 // Add _
 func (cwg *ChannelledWaitGroup) Add(delta int) {
+	cwg.mutex.Lock()
+	defer cwg.mutex.Unlock()
+
 	cwg.counter += delta
 	cwg.wg.Add(delta)
 }
 
 // Done _
 func (cwg *ChannelledWaitGroup) Done() {
-	cwg.counter--
-	cwg.wg.Done()
+	cwg.mutex.Lock()
+	defer cwg.mutex.Unlock()
+
+	cwg.done()
 }
 
 // AllDone _
 func (cwg *ChannelledWaitGroup) AllDone() {
-	for i := 0; i <= cwg.counter; i++ {
-		cwg.Done()
+	cwg.mutex.Lock()
+	defer cwg.mutex.Unlock()
+
+	for cwg.counter > 0 {
+		cwg.done()
 	}
 }
 
 // IsFinished _
 func (cwg *ChannelledWaitGroup) IsFinished() bool {
+	cwg.mutex.Lock()
+	defer cwg.mutex.Unlock()
+
 	return cwg.counter < 1
 }
 
@@ -51,3 +63,13 @@ func (cwg *ChannelledWaitGroup) Closed() <-chan struct{} {
 	close(ch)
 	return ch
 }
+
+// done is ignored when counter is already zero (i.e. after AllDone)
+func (cwg *ChannelledWaitGroup) done() {
+	if cwg.counter < 1 {
+		return
+	}
+
+	cwg.counter--
+	cwg.wg.Done()
+}
@@ -2,6 +2,7 @@ package ctxlog
 
 import (
 	"context"
+	"io"
 
 	"github.com/sirupsen/logrus"
 	prefixed "github.com/x-cray/logrus-prefixed-formatter"
@@ -34,6 +35,16 @@ func SetLevel(lvl logrus.Level) {
 	loggerInstance.SetLevel(lvl)
 }
 
+// SetOutput replaces log messages writer (stderr by default)
+func SetOutput(out io.Writer) {
+	loggerInstance.SetOutput(out)
+}
+
+// Output returns current log messages writer
+func Output() io.Writer {
+	return loggerInstance.Out
+}
+
 // FromContext _
 func FromContext(ctx context.Context, prefix string) logrus.FieldLogger {
 	logger, ok := ctx.Value(LoggerContextKey).(logrus.FieldLogger)
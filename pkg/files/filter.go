@@ -0,0 +1,110 @@
+package files
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// FilesFilter describes which files should be returned while walking through path.
+// Zero value accepts all files
+type FilesFilter struct {
+	// Include is a list of glob patterns. If it's not empty, only matched files are accepted.
+	// Patterns with slash are matched against path relative to walking root, others against file name
+	Include []string
+	// Exclude is a list of glob patterns for files & directories which should be skipped
+	Exclude []string
+	// MaxDepth limits directories nesting. 1 means only files from root path. 0 means unlimited
+	MaxDepth int
+	// SkipHidden skips files & directories which names start with dot
+	SkipHidden bool
+	// Extensions is an allowlist of file extensions (case insensitive). Examples: ".mp4", "mov"
+	Extensions []string
+}
+
+// AcceptsDir returns false if directory content should not be walked.
+// relPath is a slash separated path relative to walking root
+func (ff FilesFilter) AcceptsDir(relPath string) bool {
+	if relPath == "." || relPath == "" {
+		return true
+	}
+
+	name := filepath.Base(relPath)
+
+	if ff.SkipHidden && isHidden(name) {
+		return false
+	}
+
+	if ff.MaxDepth > 0 && pathDepth(relPath) >= ff.MaxDepth {
+		return false
+	}
+
+	return !matchesAny(ff.Exclude, relPath)
+}
+
+// AcceptsFile returns true if file should be returned.
+// relPath is a slash separated path relative to walking root
+func (ff FilesFilter) AcceptsFile(relPath string) bool {
+	name := filepath.Base(relPath)
+
+	if ff.SkipHidden && isHidden(name) {
+		return false
+	}
+
+	if len(ff.Extensions) > 0 && !hasExtension(ff.Extensions, name) {
+		return false
+	}
+
+	if matchesAny(ff.Exclude, relPath) {
+		return false
+	}
+
+	if len(ff.Include) > 0 && !matchesAny(ff.Include, relPath) {
+		return false
+	}
+
+	return true
+}
+
+func isHidden(name string) bool {
+	return strings.HasPrefix(name, ".")
+}
+
+func pathDepth(relPath string) int {
+	return len(strings.Split(relPath, "/"))
+}
+
+func hasExtension(extensions []string, name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+
+	for _, allowedExt := range extensions {
+		allowedExt = strings.ToLower(allowedExt)
+
+		if !strings.HasPrefix(allowedExt, ".") {
+			allowedExt = "." + allowedExt
+		}
+
+		if ext == allowedExt {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchesAny(patterns []string, relPath string) bool {
+	name := filepath.Base(relPath)
+
+	for _, pattern := range patterns {
+		subject := name
+
+		if strings.Contains(pattern, "/") {
+			subject = relPath
+		}
+
+		if matched, _ := filepath.Match(pattern, subject); matched {
+			return true
+		}
+	}
+
+	return false
+}
@@ -10,6 +10,7 @@ import (
 // Pather _
 type Pather interface {
 	Files() ([]Filer, error)
+	FilesWithFilter(filter FilesFilter) ([]Filer, error)
 	FullPath() string
 	Create() error
 	BuildSubpath(path string) Pather
@@ -75,6 +76,11 @@ func (p *Path) Clone() *Path {
 
 // Files _
 func (p *Path) Files() ([]Filer, error) {
+	return p.FilesWithFilter(FilesFilter{})
+}
+
+// FilesWithFilter returns files from path recursively, skipping files & directories rejected by filter
+func (p *Path) FilesWithFilter(filter FilesFilter) ([]Filer, error) {
 	files := make([]Filer, 0)
 
 	err := filepath.Walk(p.path, func(path string, info os.FileInfo, err error) error {
@@ -82,11 +88,29 @@ func (p *Path) Files() ([]Filer, error) {
 			return errors.Wrap(err, "Walking in path")
 		}
 
+		relPath, err := filepath.Rel(p.path, path)
+
+		if err != nil {
+			return errors.Wrap(err, "Getting relative path")
+		}
+
+		relPath = filepath.ToSlash(relPath)
+
+		if relPath == "." && !info.IsDir() {
+			relPath = info.Name()
+		}
+
 		if info.IsDir() {
+			if !filter.AcceptsDir(relPath) {
+				return filepath.SkipDir
+			}
+
 			return nil
 		}
 
-		files = append(files, NewFile(path))
+		if filter.AcceptsFile(relPath) {
+			files = append(files, NewFile(path))
+		}
 
 		return nil
 	})
@@ -0,0 +1,88 @@
+package files
+
+import (
+	"io/ioutil"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test__FilesWithFilter(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpDir, err := ioutil.TempDir("", "fftb_path_test")
+	assert.Nil(err)
+	defer os.RemoveAll(tmpDir)
+
+	root := NewPath(tmpDir)
+
+	for _, name := range []string{
+		"a.mp4",
+		"b.MOV",
+		"notes.txt",
+		".DS_Store",
+		"game1/c.mp4",
+		"game1/c.DVR.mp4",
+		"game1/thumbs/c.jpg",
+		".hidden/d.mp4",
+		"game2/deep/e.mp4",
+	} {
+		assert.Nil(root.BuildFile(name).Create())
+	}
+
+	testTable := []struct {
+		filter        FilesFilter
+		expectedFiles []string
+	}{
+		{
+			filter: FilesFilter{},
+			expectedFiles: []string{
+				".DS_Store", ".hidden/d.mp4", "a.mp4", "b.MOV", "game1/c.DVR.mp4",
+				"game1/c.mp4", "game1/thumbs/c.jpg", "game2/deep/e.mp4", "notes.txt",
+			},
+		},
+		{
+			filter: FilesFilter{SkipHidden: true, Extensions: []string{"mp4", ".mov"}},
+			expectedFiles: []string{
+				"a.mp4", "b.MOV", "game1/c.DVR.mp4", "game1/c.mp4", "game2/deep/e.mp4",
+			},
+		},
+		{
+			filter:        FilesFilter{SkipHidden: true, MaxDepth: 1},
+			expectedFiles: []string{"a.mp4", "b.MOV", "notes.txt"},
+		},
+		{
+			filter: FilesFilter{SkipHidden: true, MaxDepth: 2},
+			expectedFiles: []string{
+				"a.mp4", "b.MOV", "game1/c.DVR.mp4", "game1/c.mp4", "notes.txt",
+			},
+		},
+		{
+			filter:        FilesFilter{Include: []string{"*.DVR.mp4", "game2/*/*"}},
+			expectedFiles: []string{"game1/c.DVR.mp4", "game2/deep/e.mp4"},
+		},
+		{
+			filter: FilesFilter{SkipHidden: true, Exclude: []string{"thumbs", "*.txt", "game2"}},
+			expectedFiles: []string{
+				"a.mp4", "b.MOV", "game1/c.DVR.mp4", "game1/c.mp4",
+			},
+		},
+	}
+
+	for i, testItem := range testTable {
+		foundFiles, err := root.FilesWithFilter(testItem.filter)
+		assert.Nil(err, i)
+
+		relPaths := make([]string, 0)
+
+		for _, file := range foundFiles {
+			relPaths = append(relPaths, file.FullPath()[len(root.FullPath())+1:])
+		}
+
+		sort.Strings(relPaths)
+
+		assert.Equal(testItem.expectedFiles, relPaths, i)
+	}
+}
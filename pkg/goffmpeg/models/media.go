@@ -19,7 +19,7 @@ type Mediafile struct {
 	videoMinBitrate       int
 	videoCodec            string
 	vframes               int
-	frameRate             int
+	frameRate             string
 	audioRate             int
 	maxKeyframe           int
 	minKeyframe           int
@@ -69,6 +69,11 @@ type Mediafile struct {
 	httpMethod            string
 	httpKeepAlive         bool
 	hwaccel               string
+	hwaccelDevice         string
+	hwaccelOutputFormat   string
+	initHWDevice          string
+	filterHWDevice        string
+	qsvDevice             string
 	vsync                 bool
 	inputVideoCodec       string
 	streamIds             map[int]string
@@ -94,7 +99,17 @@ type Mediafile struct {
 	constantQuantization     int
 	nvencTargetQuality       int
 	libx265Params            *Libx265Params
+	cpuUsed                  int
+	globalQuality            int
+	rateControlMode          string
 	mapFlag                  string
+	streamMaps               []string
+	complexFilter            string
+	subtitleCodec            string
+	dataCodec                string
+	videoSyncMethod          string
+	pass                     int
+	passLogFile              string
 	segmentTime              int
 	resetTimestamps          bool
 }
@@ -103,6 +118,9 @@ type Mediafile struct {
 type Libx265Params struct {
 	CRF uint32
 	QP  uint32
+	// Pass is a number of two-pass encoding pass. libx265 ignores -pass option
+	Pass  int
+	Stats string
 }
 
 /*** SETTERS ***/
@@ -168,7 +186,7 @@ func (m *Mediafile) SetVframes(v int) {
 }
 
 // SetFrameRate _
-func (m *Mediafile) SetFrameRate(v int) {
+func (m *Mediafile) SetFrameRate(v string) {
 	m.frameRate = v
 }
 
@@ -303,6 +321,11 @@ func (m *Mediafile) SetLibx265Params(v *Libx265Params) {
 	m.libx265Params = v
 }
 
+// SetCPUUsed _
+func (m *Mediafile) SetCPUUsed(v int) {
+	m.cpuUsed = v
+}
+
 // SetStrict _
 func (m *Mediafile) SetStrict(v int) {
 	m.strict = v
@@ -363,6 +386,41 @@ func (m *Mediafile) SetMap(val string) {
 	m.mapFlag = val
 }
 
+// SetStreamMaps sets list of -map options
+func (m *Mediafile) SetStreamMaps(val []string) {
+	m.streamMaps = val
+}
+
+// SetComplexFilter _
+func (m *Mediafile) SetComplexFilter(val string) {
+	m.complexFilter = val
+}
+
+// SetSubtitleCodec _
+func (m *Mediafile) SetSubtitleCodec(val string) {
+	m.subtitleCodec = val
+}
+
+// SetDataCodec _
+func (m *Mediafile) SetDataCodec(val string) {
+	m.dataCodec = val
+}
+
+// SetPass _
+func (m *Mediafile) SetPass(val int) {
+	m.pass = val
+}
+
+// SetPassLogFile _
+func (m *Mediafile) SetPassLogFile(val string) {
+	m.passLogFile = val
+}
+
+// SetVideoSyncMethod sets -vsync value (passthrough, cfr, vfr, drop, auto). Use instead of SetVsync
+func (m *Mediafile) SetVideoSyncMethod(val string) {
+	m.videoSyncMethod = val
+}
+
 // SetSegmentTime _
 func (m *Mediafile) SetSegmentTime(val int) {
 	m.segmentTime = val
@@ -453,6 +511,41 @@ func (m *Mediafile) SetHardwareAcceleration(val string) {
 	m.hwaccel = val
 }
 
+// SetHardwareAccelerationDevice _
+func (m *Mediafile) SetHardwareAccelerationDevice(val string) {
+	m.hwaccelDevice = val
+}
+
+// SetHardwareAccelerationOutputFormat _
+func (m *Mediafile) SetHardwareAccelerationOutputFormat(val string) {
+	m.hwaccelOutputFormat = val
+}
+
+// SetInitHardwareDevice _
+func (m *Mediafile) SetInitHardwareDevice(val string) {
+	m.initHWDevice = val
+}
+
+// SetFilterHardwareDevice _
+func (m *Mediafile) SetFilterHardwareDevice(val string) {
+	m.filterHWDevice = val
+}
+
+// SetQsvDevice _
+func (m *Mediafile) SetQsvDevice(val string) {
+	m.qsvDevice = val
+}
+
+// SetGlobalQuality _
+func (m *Mediafile) SetGlobalQuality(v int) {
+	m.globalQuality = v
+}
+
+// SetRateControlMode _
+func (m *Mediafile) SetRateControlMode(v string) {
+	m.rateControlMode = v
+}
+
 // SetVsync _
 func (m *Mediafile) SetVsync(val bool) {
 	m.vsync = val
@@ -576,7 +669,7 @@ func (m *Mediafile) Vframes() int {
 }
 
 // FrameRate _
-func (m *Mediafile) FrameRate() int {
+func (m *Mediafile) FrameRate() string {
 	return m.frameRate
 }
 
@@ -710,6 +803,11 @@ func (m *Mediafile) Libx265Params() *Libx265Params {
 	return m.libx265Params
 }
 
+// CPUUsed _
+func (m *Mediafile) CPUUsed() int {
+	return m.cpuUsed
+}
+
 // Strict _
 func (m *Mediafile) Strict() int {
 	return m.strict
@@ -775,6 +873,41 @@ func (m *Mediafile) Map() string {
 	return m.mapFlag
 }
 
+// StreamMaps _
+func (m *Mediafile) StreamMaps() []string {
+	return m.streamMaps
+}
+
+// ComplexFilter _
+func (m *Mediafile) ComplexFilter() string {
+	return m.complexFilter
+}
+
+// SubtitleCodec _
+func (m *Mediafile) SubtitleCodec() string {
+	return m.subtitleCodec
+}
+
+// DataCodec _
+func (m *Mediafile) DataCodec() string {
+	return m.dataCodec
+}
+
+// Pass _
+func (m *Mediafile) Pass() int {
+	return m.pass
+}
+
+// PassLogFile _
+func (m *Mediafile) PassLogFile() string {
+	return m.passLogFile
+}
+
+// VideoSyncMethod _
+func (m *Mediafile) VideoSyncMethod() string {
+	return m.videoSyncMethod
+}
+
 // SegmentTime _
 func (m *Mediafile) SegmentTime() int {
 	return m.segmentTime
@@ -865,6 +998,41 @@ func (m *Mediafile) HardwareAcceleration() string {
 	return m.hwaccel
 }
 
+// HardwareAccelerationDevice _
+func (m *Mediafile) HardwareAccelerationDevice() string {
+	return m.hwaccelDevice
+}
+
+// HardwareAccelerationOutputFormat _
+func (m *Mediafile) HardwareAccelerationOutputFormat() string {
+	return m.hwaccelOutputFormat
+}
+
+// InitHardwareDevice _
+func (m *Mediafile) InitHardwareDevice() string {
+	return m.initHWDevice
+}
+
+// FilterHardwareDevice _
+func (m *Mediafile) FilterHardwareDevice() string {
+	return m.filterHWDevice
+}
+
+// QsvDevice _
+func (m *Mediafile) QsvDevice() string {
+	return m.qsvDevice
+}
+
+// GlobalQuality _
+func (m *Mediafile) GlobalQuality() int {
+	return m.globalQuality
+}
+
+// RateControlMode _
+func (m *Mediafile) RateControlMode() string {
+	return m.rateControlMode
+}
+
 // StreamIds _
 func (m *Mediafile) StreamIds() map[int]string {
 	return m.streamIds
@@ -929,18 +1097,26 @@ func (m *Mediafile) ToStrCommand() []string {
 		"DurationInput",
 		"RtmpLive",
 		"InputInitialOffset",
+		"InitHardwareDevice",
+		"FilterHardwareDevice",
 		"HardwareAcceleration",
+		"HardwareAccelerationDevice",
+		"HardwareAccelerationOutputFormat",
+		"QsvDevice",
 		"Vsync",
 		"InputVideoCodec",
 		"InputFormat",
 		"InputPath",
 		"InputPipe",
+		"ComplexFilter",
 		"Map",
+		"StreamMaps",
 		"HideBanner",
 		"FileSizeLimit",
 		"Aspect",
 		"Resolution",
 		"FrameRate",
+		"VideoSyncMethod",
 		"AudioRate",
 		"VideoCodec",
 		"Vframes",
@@ -955,10 +1131,14 @@ func (m *Mediafile) ToStrCommand() []string {
 		"AudioChannels",
 		"AudioProfile",
 		"SkipAudio",
+		"SubtitleCodec",
+		"DataCodec",
 		"CRF",
 		"Libx265Params",
 		"QScale",
 		"NvencRateControl",
+		"RateControlMode",
+		"GlobalQuality",
 		"MaxMuxingQueueSize",
 		"ConstantQuantization",
 		"NvencTargetQuality",
@@ -967,7 +1147,10 @@ func (m *Mediafile) ToStrCommand() []string {
 		"MuxDelay",
 		"Threads",
 		"KeyframeInterval",
+		"Pass",
+		"PassLogFile",
 		"Preset",
+		"CPUUsed",
 		"PixFmt",
 		"Tune",
 		"Target",
@@ -1067,6 +1250,51 @@ func (m *Mediafile) ObtainHardwareAcceleration() []string {
 	return nil
 }
 
+// ObtainHardwareAccelerationDevice _
+func (m *Mediafile) ObtainHardwareAccelerationDevice() []string {
+	if m.hwaccelDevice != "" {
+		return []string{"-hwaccel_device", m.hwaccelDevice}
+	}
+
+	return nil
+}
+
+// ObtainHardwareAccelerationOutputFormat _
+func (m *Mediafile) ObtainHardwareAccelerationOutputFormat() []string {
+	if m.hwaccelOutputFormat != "" {
+		return []string{"-hwaccel_output_format", m.hwaccelOutputFormat}
+	}
+
+	return nil
+}
+
+// ObtainInitHardwareDevice _
+func (m *Mediafile) ObtainInitHardwareDevice() []string {
+	if m.initHWDevice != "" {
+		return []string{"-init_hw_device", m.initHWDevice}
+	}
+
+	return nil
+}
+
+// ObtainFilterHardwareDevice _
+func (m *Mediafile) ObtainFilterHardwareDevice() []string {
+	if m.filterHWDevice != "" {
+		return []string{"-filter_hw_device", m.filterHWDevice}
+	}
+
+	return nil
+}
+
+// ObtainQsvDevice _
+func (m *Mediafile) ObtainQsvDevice() []string {
+	if m.qsvDevice != "" {
+		return []string{"-qsv_device", m.qsvDevice}
+	}
+
+	return nil
+}
+
 // ObtainVsync _
 func (m *Mediafile) ObtainVsync() []string {
 	if m.vsync {
@@ -1130,6 +1358,75 @@ func (m *Mediafile) ObtainMap() []string {
 	return nil
 }
 
+// ObtainStreamMaps _
+func (m *Mediafile) ObtainStreamMaps() []string {
+	if len(m.streamMaps) == 0 {
+		return nil
+	}
+
+	result := []string{}
+
+	for _, val := range m.streamMaps {
+		result = append(result, "-map", val)
+	}
+
+	return result
+}
+
+// ObtainComplexFilter _
+func (m *Mediafile) ObtainComplexFilter() []string {
+	if m.complexFilter != "" {
+		return []string{"-filter_complex", m.complexFilter}
+	}
+
+	return nil
+}
+
+// ObtainSubtitleCodec _
+func (m *Mediafile) ObtainSubtitleCodec() []string {
+	if m.subtitleCodec != "" {
+		return []string{"-c:s", m.subtitleCodec}
+	}
+
+	return nil
+}
+
+// ObtainDataCodec _
+func (m *Mediafile) ObtainDataCodec() []string {
+	if m.dataCodec != "" {
+		return []string{"-c:d", m.dataCodec}
+	}
+
+	return nil
+}
+
+// ObtainPass _
+func (m *Mediafile) ObtainPass() []string {
+	if m.pass != 0 {
+		return []string{"-pass", fmt.Sprintf("%d", m.pass)}
+	}
+
+	return nil
+}
+
+// ObtainPassLogFile _
+func (m *Mediafile) ObtainPassLogFile() []string {
+	if m.passLogFile != "" {
+		return []string{"-passlogfile", m.passLogFile}
+	}
+
+	return nil
+}
+
+// ObtainVideoSyncMethod _
+func (m *Mediafile) ObtainVideoSyncMethod() []string {
+	if m.videoSyncMethod != "" {
+		return []string{"-vsync", m.videoSyncMethod}
+	}
+
+	return nil
+}
+
 // ObtainSegmentTime _
 func (m *Mediafile) ObtainSegmentTime() []string {
 	if m.segmentTime != 0 {
@@ -1204,8 +1501,8 @@ func (m *Mediafile) ObtainVframes() []string {
 
 // ObtainFrameRate _
 func (m *Mediafile) ObtainFrameRate() []string {
-	if m.frameRate != 0 {
-		return []string{"-r", fmt.Sprintf("%d", m.frameRate)}
+	if m.frameRate != "" {
+		return []string{"-r", m.frameRate}
 	}
 
 	return nil
@@ -1409,8 +1706,16 @@ func (m *Mediafile) ObtainLibx265Params() []string {
 			flags = append(flags, fmt.Sprintf("qp=%d", m.libx265Params.QP))
 		}
 
+		if m.libx265Params.Pass > 0 {
+			flags = append(flags, fmt.Sprintf("pass=%d", m.libx265Params.Pass))
+		}
+
+		if m.libx265Params.Stats != "" {
+			flags = append(flags, fmt.Sprintf("stats=%s", m.libx265Params.Stats))
+		}
+
 		if len(flags) > 0 {
-			return []string{"-x265-params", strings.Join(flags, " ")}
+			return []string{"-x265-params", strings.Join(flags, ":")}
 		}
 	}
 
@@ -1435,6 +1740,24 @@ func (m *Mediafile) ObtainNvencRateControl() []string {
 	return nil
 }
 
+// ObtainRateControlMode _
+func (m *Mediafile) ObtainRateControlMode() []string {
+	if m.rateControlMode != "" {
+		return []string{"-rc_mode", m.rateControlMode}
+	}
+
+	return nil
+}
+
+// ObtainGlobalQuality _
+func (m *Mediafile) ObtainGlobalQuality() []string {
+	if m.globalQuality > 0 {
+		return []string{"-global_quality", fmt.Sprintf("%d", m.globalQuality)}
+	}
+
+	return nil
+}
+
 // ObtainMaxMuxingQueueSize _
 func (m *Mediafile) ObtainMaxMuxingQueueSize() []string {
 	if m.maxMuxingQueueSize != 0 {
@@ -1462,6 +1785,15 @@ func (m *Mediafile) ObtainNvencTargetQuality() []string {
 	return nil
 }
 
+// ObtainCPUUsed _
+func (m *Mediafile) ObtainCPUUsed() []string {
+	if m.cpuUsed > 0 {
+		return []string{"-cpu-used", fmt.Sprintf("%d", m.cpuUsed)}
+	}
+
+	return nil
+}
+
 // ObtainStrict _
 func (m *Mediafile) ObtainStrict() []string {
 	if m.strict != 0 {
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMediafile_ObtainLibx265Params(t *testing.T) {
+	testTable := []struct {
+		name     string
+		params   *Libx265Params
+		expected []string
+	}{
+		{
+			name:     "not set",
+			params:   nil,
+			expected: nil,
+		},
+		{
+			name:     "single param",
+			params:   &Libx265Params{CRF: 28},
+			expected: []string{"-x265-params", "crf=28"},
+		},
+		{
+			// x265 params are separated by colon, space would be passed as part of value
+			name:     "multiple params",
+			params:   &Libx265Params{Pass: 2, Stats: "/tmp/passlog.log"},
+			expected: []string{"-x265-params", "pass=2:stats=/tmp/passlog.log"},
+		},
+	}
+
+	for _, testItem := range testTable {
+		t.Run(testItem.name, func(t *testing.T) {
+			mediaFile := &Mediafile{}
+			mediaFile.SetLibx265Params(testItem.params)
+
+			assert.Equal(t, testItem.expected, mediaFile.ObtainLibx265Params())
+		})
+	}
+}
@@ -16,6 +16,28 @@ type Metadata struct {
 	Format  Format    `json:"format"`
 }
 
+// DurationSeconds returns media duration in seconds. If container duration is missing
+// (i.e. in some mkv & ts files), the longest stream duration is used. Returns 0 if duration is unknown
+func (m Metadata) DurationSeconds() float64 {
+	duration, err := strconv.ParseFloat(m.Format.Duration, 64)
+
+	if err == nil && duration > 0 {
+		return duration
+	}
+
+	duration = 0
+
+	for _, stream := range m.Streams {
+		streamDuration, err := strconv.ParseFloat(stream.Duration, 64)
+
+		if err == nil && streamDuration > duration {
+			duration = streamDuration
+		}
+	}
+
+	return duration
+}
+
 // Streams _
 type Streams struct {
 	Index              int         `json:"index"`
@@ -47,6 +69,8 @@ type Streams struct {
 	Duration           string      `json:"duration"`
 	Disposition        Disposition `json:"disposition"`
 	BitRate            string      `json:"bit_rate"`
+	Channels           int         `json:"channels"`
+	Tags               StreamTags  `json:"tags"`
 
 	DurationFloat float64
 }
@@ -217,3 +241,9 @@ type Progress struct {
 type Tags struct {
 	Encoder string `json:"ENCODER"`
 }
+
+// StreamTags _
+type StreamTags struct {
+	Language string `json:"language"`
+	Title    string `json:"title"`
+}
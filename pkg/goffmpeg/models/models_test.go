@@ -0,0 +1,39 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadata_DurationSeconds(t *testing.T) {
+	testTable := []struct {
+		name     string
+		metadata Metadata
+		expected float64
+	}{
+		{
+			name:     "format duration",
+			metadata: Metadata{Format: Format{Duration: "60.5"}, Streams: []Streams{{Duration: "61"}}},
+			expected: 60.5,
+		},
+		{
+			name: "longest stream duration",
+			metadata: Metadata{Streams: []Streams{
+				{CodecType: "video", Duration: "59.9"},
+				{CodecType: "audio", Duration: "60.1"},
+				{CodecType: "subtitle", Duration: "N/A"},
+			}},
+			expected: 60.1,
+		},
+		{
+			name:     "unknown",
+			metadata: Metadata{Format: Format{Duration: "N/A"}},
+			expected: 0,
+		},
+	}
+
+	for _, testItem := range testTable {
+		assert.Equal(t, testItem.expected, testItem.metadata.DurationSeconds(), testItem.name)
+	}
+}
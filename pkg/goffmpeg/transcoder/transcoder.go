@@ -12,6 +12,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -31,11 +32,45 @@ type Transcoder struct {
 	configuration ffmpeg.Configuration
 	ctx           context.Context
 	logger        logrus.FieldLogger
+	inputMetadata *models.Metadata
 }
 
 // LoggingPrefix _
 const LoggingPrefix = "goffmpeg"
 
+// running contains all started ffmpeg processes, so they can be killed on forced exit
+var running = struct {
+	sync.Mutex
+	processes map[*exec.Cmd]struct{}
+}{processes: make(map[*exec.Cmd]struct{})}
+
+// KillAll kills all running ffmpeg processes. Should be used before forced exit only,
+// because killed processes leave broken output files
+func KillAll() {
+	running.Lock()
+	defer running.Unlock()
+
+	for proc := range running.processes {
+		if proc.Process != nil {
+			proc.Process.Kill()
+		}
+	}
+}
+
+func trackProcess(proc *exec.Cmd) {
+	running.Lock()
+	defer running.Unlock()
+
+	running.processes[proc] = struct{}{}
+}
+
+func untrackProcess(proc *exec.Cmd) {
+	running.Lock()
+	defer running.Unlock()
+
+	delete(running.processes, proc)
+}
+
 // New _
 func New(ctx context.Context) *Transcoder {
 	var logger logrus.FieldLogger
@@ -69,6 +104,11 @@ func (t *Transcoder) SetMediaFile(v *models.Mediafile) {
 	t.mediafile = v
 }
 
+// SetInputMetadata Set already known input file metadata, so Initialize will not run ffprobe again
+func (t *Transcoder) SetInputMetadata(v models.Metadata) {
+	t.inputMetadata = &v
+}
+
 // SetConfiguration Set the transcoding configuration
 func (t *Transcoder) SetConfiguration(v ffmpeg.Configuration) {
 	t.configuration = v
@@ -187,7 +227,9 @@ func (t *Transcoder) Initialize(inputPath string, outputPath string) error {
 	MediaFile.SetInputPath(inputPath)
 	MediaFile.SetOutputPath(outputPath)
 
-	if isFilePossiblyHasMetadata(inputPath) {
+	if t.inputMetadata != nil {
+		MediaFile.SetMetadata(*t.inputMetadata)
+	} else if isFilePossiblyHasMetadata(inputPath) {
 		metadata, err := t.GetFileMetadata(inputPath)
 
 		if err != nil {
@@ -392,7 +434,9 @@ func (t *Transcoder) Run(progress bool) <-chan error {
 			return
 		}
 
+		trackProcess(proc)
 		err = proc.Wait()
+		untrackProcess(proc)
 
 		go t.closePipes()
 
@@ -514,7 +558,7 @@ func (t Transcoder) Output() chan models.Progress {
 				}
 
 				timesec := utils.DurToSec(currentTime)
-				dursec, _ := strconv.ParseFloat(t.MediaFile().Metadata().Format.Duration, 64)
+				dursec := t.MediaFile().Metadata().DurationSeconds()
 				//live stream check
 				if dursec != 0 {
 					// Progress calculation
@@ -0,0 +1,64 @@
+package jobs
+
+import (
+	"time"
+
+	"github.com/wailorman/fftb/pkg/media/convert"
+)
+
+// Event types
+const (
+	JobStartedEvent   = "job_started"
+	TaskStartedEvent  = "task_started"
+	ProgressEvent     = "progress"
+	TaskFinishedEvent = "task_finished"
+	TaskFailedEvent   = "task_failed"
+	TaskSkippedEvent  = "task_skipped"
+	JobFinishedEvent  = "job_finished"
+)
+
+// subscriberBufferSize limits events kept for slow subscriber. Newer events are dropped when buffer is full
+const subscriberBufferSize = 64
+
+// Event is sent to job subscribers
+type Event struct {
+	Type  string    `json:"type"`
+	Time  time.Time `json:"time"`
+	JobID string    `json:"job_id"`
+	State State     `json:"state"`
+
+	TaskID    string `json:"task_id,omitempty"`
+	InputFile string `json:"input_file,omitempty"`
+	Attempt   int    `json:"attempt,omitempty"`
+	WillRetry bool   `json:"will_retry,omitempty"`
+	Error     string `json:"error,omitempty"`
+
+	// TaskProgress is in range 0-100
+	TaskProgress float64   `json:"task_progress,omitempty"`
+	Progress     *Progress `json:"progress,omitempty"`
+}
+
+func newTaskEvent(eventType string, job *Job, task convert.Task, attempt int) Event {
+	return Event{
+		Type:      eventType,
+		Time:      time.Now(),
+		JobID:     job.ID,
+		State:     job.State,
+		TaskID:    task.ID,
+		InputFile: task.InFile,
+		Attempt:   attempt,
+	}
+}
+
+func newJobEvent(eventType string, job *Job) Event {
+	progress := job.Progress
+
+	return Event{
+		Type:     eventType,
+		Time:     time.Now(),
+		JobID:    job.ID,
+		State:    job.State,
+		Error:    job.Error,
+		Progress: &progress,
+	}
+}
@@ -0,0 +1,197 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/wailorman/fftb/pkg/media/convert"
+	"gopkg.in/yaml.v2"
+)
+
+// maxRequestSize limits submitted batch task size
+const maxRequestSize = 10 << 20
+
+// keepAliveInterval is a period of comments sent to idle event streams, so proxies do not close connection
+const keepAliveInterval = 15 * time.Second
+
+type handler struct {
+	ctx     context.Context
+	manager *Manager
+}
+
+// NewHandler returns HTTP API handler:
+//
+//	POST /jobs               submit batch task (YAML or JSON, the same schema as `fftb convert --dry-run` output)
+//	GET  /jobs               list jobs
+//	GET  /jobs/{id}          get job
+//	POST /jobs/{id}/cancel   cancel job
+//	GET  /jobs/{id}/events   job events stream (Server-Sent Events)
+//
+// Event streams are closed when context is cancelled
+func NewHandler(ctx context.Context, manager *Manager) http.Handler {
+	return &handler{ctx: ctx, manager: manager}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	if parts[0] != "jobs" {
+		writeError(w, http.StatusNotFound, errors.New("Not found"))
+		return
+	}
+
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodPost:
+		h.submit(w, r)
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, h.manager.Jobs())
+	case len(parts) == 2 && r.Method == http.MethodGet:
+		h.get(w, parts[1])
+	case len(parts) == 3 && parts[2] == "cancel" && r.Method == http.MethodPost:
+		h.cancel(w, parts[1])
+	case len(parts) == 3 && parts[2] == "events" && r.Method == http.MethodGet:
+		h.events(w, r, parts[1])
+	case len(parts) <= 3:
+		writeError(w, http.StatusMethodNotAllowed, errors.New("Method not allowed"))
+	default:
+		writeError(w, http.StatusNotFound, errors.New("Not found"))
+	}
+}
+
+func (h *handler) submit(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxRequestSize))
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.Wrap(err, "Reading request body"))
+		return
+	}
+
+	var batchTask convert.BatchTask
+
+	// JSON is a subset of YAML, so both formats are parsed the same way
+	err = yaml.Unmarshal(body, &batchTask)
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.Wrap(err, "Parsing batch task"))
+		return
+	}
+
+	job, err := h.manager.Submit(batchTask)
+
+	if errors.Cause(err) == ErrInvalidBatchTask {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, job)
+}
+
+func (h *handler) get(w http.ResponseWriter, id string) {
+	job, err := h.manager.Job(id)
+
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, job)
+}
+
+func (h *handler) cancel(w http.ResponseWriter, id string) {
+	job, err := h.manager.Cancel(id)
+
+	switch errors.Cause(err) {
+	case nil:
+		writeJSON(w, http.StatusAccepted, job)
+	case ErrJobNotFound:
+		writeError(w, http.StatusNotFound, err)
+	case ErrJobFinished:
+		writeError(w, http.StatusConflict, err)
+	default:
+		writeError(w, http.StatusInternalServerError, err)
+	}
+}
+
+// events streams job events. Current job state is sent first as `job` event
+func (h *handler) events(w http.ResponseWriter, r *http.Request, id string) {
+	flusher, ok := w.(http.Flusher)
+
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("Streaming is not supported"))
+		return
+	}
+
+	events, unsubscribe, job, err := h.manager.Subscribe(id)
+
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	writeEvent(w, "job", job)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+
+			writeEvent(w, event.Type, event)
+
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+
+		case <-r.Context().Done():
+			return
+
+		case <-h.ctx.Done():
+			return
+		}
+
+		flusher.Flush()
+	}
+}
+
+func writeEvent(w io.Writer, eventType string, data interface{}) {
+	d, err := json.Marshal(data)
+
+	if err != nil {
+		return
+	}
+
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", eventType, d)
+}
+
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	json.NewEncoder(w).Encode(data)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
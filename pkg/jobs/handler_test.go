@@ -0,0 +1,98 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wailorman/fftb/pkg/files"
+	"github.com/wailorman/fftb/pkg/media/convert"
+)
+
+func Test__handler(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpDir, err := ioutil.TempDir("", "fftb_jobs_test")
+	assert.Nil(err)
+	defer os.RemoveAll(tmpDir)
+
+	// manager is not started, so submitted jobs stay queued
+	manager := NewManager(files.NewPath(tmpDir), nil)
+	server := httptest.NewServer(NewHandler(context.Background(), manager))
+	defer server.Close()
+
+	request := func(method, path, body string) (int, map[string]interface{}) {
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		assert.Nil(err)
+
+		res, err := http.DefaultClient.Do(req)
+		assert.Nil(err)
+		defer res.Body.Close()
+
+		var data map[string]interface{}
+		json.NewDecoder(res.Body).Decode(&data)
+
+		return res.StatusCode, data
+	}
+
+	status, _ := request(http.MethodPost, "/jobs", "tasks: []")
+	assert.Equal(http.StatusBadRequest, status)
+
+	status, yamlJob := request(http.MethodPost, "/jobs", "parallelism: 2\ntasks:\n- in_file: a.mp4\n  out_file: out/a.mp4\n")
+	assert.Equal(http.StatusCreated, status)
+	assert.Equal(string(QueuedState), yamlJob["state"])
+
+	status, jsonJob := request(http.MethodPost, "/jobs", `{"tasks": [{"id": "x", "in_file": "b.mp4", "out_file": "out/b.mp4"}]}`)
+	assert.Equal(http.StatusCreated, status)
+
+	jobs := manager.Jobs()
+	assert.Len(jobs, 2)
+	assert.Equal(2, jobs[0].Task.Parallelism)
+	assert.Equal("0", jobs[0].Tasks[0].ID)
+	assert.Equal("x", jobs[1].Tasks[0].ID)
+	assert.NotEmpty(jobs[1].Task.JournalPath)
+
+	status, data := request(http.MethodGet, "/jobs/"+jsonJob["id"].(string), "")
+	assert.Equal(http.StatusOK, status)
+	assert.Equal("b.mp4", data["tasks"].([]interface{})[0].(map[string]interface{})["in_file"])
+
+	status, _ = request(http.MethodGet, "/jobs/missing", "")
+	assert.Equal(http.StatusNotFound, status)
+
+	status, data = request(http.MethodPost, "/jobs/"+yamlJob["id"].(string)+"/cancel", "")
+	assert.Equal(http.StatusAccepted, status)
+	assert.Equal(string(CancelledState), data["state"])
+
+	status, _ = request(http.MethodPost, "/jobs/"+yamlJob["id"].(string)+"/cancel", "")
+	assert.Equal(http.StatusConflict, status)
+
+	// event stream of finished job contains only current state
+	res, err := http.Get(server.URL + "/jobs/" + yamlJob["id"].(string) + "/events")
+	assert.Nil(err)
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	assert.Nil(err)
+	assert.Equal("text/event-stream", res.Header.Get("Content-Type"))
+	assert.True(strings.HasPrefix(string(body), "event: job\ndata: {"))
+
+	// queue is restored after restart
+	restartedManager := NewManager(files.NewPath(tmpDir), nil)
+	assert.Nil(restartedManager.Load())
+
+	restoredJob, err := restartedManager.Job(jsonJob["id"].(string))
+	assert.Nil(err)
+	assert.Equal(QueuedState, restoredJob.State)
+	assert.True(restoredJob.Task.Resume)
+	assert.Equal(convert.JournalTaskPending, restoredJob.Tasks[0].State)
+	assert.Equal([]string{restoredJob.ID}, restartedManager.pending)
+
+	cancelledJob, err := restartedManager.Job(yamlJob["id"].(string))
+	assert.Nil(err)
+	assert.Equal(CancelledState, cancelledJob.State)
+}
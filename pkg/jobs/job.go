@@ -0,0 +1,145 @@
+package jobs
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/wailorman/fftb/pkg/media/convert"
+)
+
+// State _
+type State string
+
+const (
+	// QueuedState _
+	QueuedState State = "queued"
+	// RunningState _
+	RunningState State = "running"
+	// DoneState _
+	DoneState State = "done"
+	// FailedState is set if at least one task is failed
+	FailedState State = "failed"
+	// CancelledState _
+	CancelledState State = "cancelled"
+)
+
+// ErrJobNotFound _
+var ErrJobNotFound = errors.New("Job not found")
+
+// ErrJobFinished _
+var ErrJobFinished = errors.New("Job is already finished")
+
+// ErrInvalidBatchTask _
+var ErrInvalidBatchTask = errors.New("Invalid batch task")
+
+// Job is a batch conversion submitted to server
+type Job struct {
+	ID    string            `yaml:"id" json:"id"`
+	State State             `yaml:"state" json:"state"`
+	Task  convert.BatchTask `yaml:"task" json:"-"`
+	// Tasks contains state of each task of batch
+	Tasks    []TaskStatus `yaml:"tasks" json:"tasks"`
+	Progress Progress     `yaml:"-" json:"progress"`
+	Error    string       `yaml:"error,omitempty" json:"error,omitempty"`
+
+	CreatedAt  time.Time  `yaml:"created_at" json:"created_at"`
+	StartedAt  *time.Time `yaml:"started_at,omitempty" json:"started_at,omitempty"`
+	FinishedAt *time.Time `yaml:"finished_at,omitempty" json:"finished_at,omitempty"`
+}
+
+// TaskStatus _
+type TaskStatus struct {
+	ID         string                   `yaml:"id" json:"id"`
+	InFile     string                   `yaml:"in_file" json:"in_file"`
+	OutFile    string                   `yaml:"out_file" json:"out_file"`
+	State      convert.JournalTaskState `yaml:"state" json:"state"`
+	ResultFile string                   `yaml:"result_file,omitempty" json:"result_file,omitempty"`
+	Error      string                   `yaml:"error,omitempty" json:"error,omitempty"`
+}
+
+// Progress is a batch progress of running job
+type Progress struct {
+	// Percent is in range 0-100
+	Percent float64 `json:"percent"`
+	// Speed is a realtime multiplier of all workers together
+	Speed float64 `json:"speed"`
+	// ETA is estimated time in seconds until job is finished
+	ETA float64 `json:"eta"`
+}
+
+// IsFinished _
+func (j *Job) IsFinished() bool {
+	return j.State == DoneState || j.State == FailedState || j.State == CancelledState
+}
+
+func (j *Job) clone() Job {
+	jobCopy := *j
+	jobCopy.Tasks = append([]TaskStatus(nil), j.Tasks...)
+	jobCopy.Task.Tasks = append([]convert.Task(nil), j.Task.Tasks...)
+
+	return jobCopy
+}
+
+func (j *Job) taskStatus(taskID string) *TaskStatus {
+	for i := range j.Tasks {
+		if j.Tasks[i].ID == taskID {
+			return &j.Tasks[i]
+		}
+	}
+
+	return nil
+}
+
+// newJob validates batch task & builds job for it. Tasks without ID receive their index as ID
+// (the same way as BatchConverter does)
+func newJob(id string, batchTask convert.BatchTask, now time.Time) (*Job, error) {
+	if len(batchTask.Tasks) == 0 {
+		return nil, errors.Wrap(ErrInvalidBatchTask, "No tasks")
+	}
+
+	if batchTask.Parallelism < 1 {
+		batchTask.Parallelism = 1
+	}
+
+	job := &Job{
+		ID:        id,
+		State:     QueuedState,
+		Task:      batchTask,
+		Tasks:     make([]TaskStatus, 0, len(batchTask.Tasks)),
+		CreatedAt: now,
+	}
+
+	job.Task.Tasks = append([]convert.Task(nil), batchTask.Tasks...)
+	taskIDs := make(map[string]bool)
+
+	for i, task := range job.Task.Tasks {
+		if task.ID == "" {
+			task.ID = strconv.Itoa(i)
+			job.Task.Tasks[i].ID = task.ID
+		}
+
+		if task.InFile == "" {
+			return nil, errors.Wrapf(ErrInvalidBatchTask, "Task `%s` has no input file", task.ID)
+		}
+
+		if task.OutFile == "" && !task.Params.ReplaceOriginal {
+			return nil, errors.Wrapf(ErrInvalidBatchTask, "Task `%s` has no output file", task.ID)
+		}
+
+		if taskIDs[task.ID] {
+			return nil, errors.Wrapf(ErrInvalidBatchTask, "Task ID `%s` is not unique", task.ID)
+		}
+
+		taskIDs[task.ID] = true
+
+		job.Tasks = append(job.Tasks, TaskStatus{
+			ID:      task.ID,
+			InFile:  task.InFile,
+			OutFile: task.OutFile,
+			State:   convert.JournalTaskPending,
+		})
+	}
+
+	return job, nil
+}
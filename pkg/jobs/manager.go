@@ -0,0 +1,516 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/wailorman/fftb/pkg/files"
+	"github.com/wailorman/fftb/pkg/media/convert"
+	"github.com/wailorman/fftb/pkg/media/minfo"
+)
+
+// Manager keeps jobs queue & converts queued jobs one by one (or several at once, see Start()).
+// Jobs are saved to state directory on each change, so queue is restored after restart
+type Manager struct {
+	store        *Store
+	journalsPath files.Pather
+	infoGetter   minfo.Getter
+
+	ctx     context.Context
+	wg      sync.WaitGroup
+	wakeup  chan struct{}
+	mutex   sync.Mutex
+	jobs    map[string]*Job
+	pending []string
+	cancels map[string]context.CancelFunc
+	// cancelled contains running jobs which were cancelled by user
+	cancelled   map[string]bool
+	subscribers map[string][]chan Event
+}
+
+// NewManager _
+func NewManager(statePath files.Pather, infoGetter minfo.Getter) *Manager {
+	return &Manager{
+		store:        NewStore(statePath.BuildSubpath("jobs")),
+		journalsPath: statePath.BuildSubpath("journals"),
+		infoGetter:   infoGetter,
+
+		ctx:         context.Background(),
+		wakeup:      make(chan struct{}, 1),
+		jobs:        make(map[string]*Job),
+		pending:     make([]string, 0),
+		cancels:     make(map[string]context.CancelFunc),
+		cancelled:   make(map[string]bool),
+		subscribers: make(map[string][]chan Event),
+	}
+}
+
+// Load reads saved jobs. Jobs which were running while previous server shutdown are queued again
+// & resumed from their journals
+func (m *Manager) Load() error {
+	savedJobs, err := m.store.LoadAll()
+
+	if err != nil {
+		return errors.Wrap(err, "Loading jobs")
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for i := range savedJobs {
+		job := savedJobs[i]
+
+		if !job.IsFinished() {
+			m.requeue(&job)
+
+			if err := m.store.Save(job); err != nil {
+				return errors.Wrapf(err, "Saving job `%s`", job.ID)
+			}
+
+			m.pending = append(m.pending, job.ID)
+		}
+
+		m.jobs[job.ID] = &job
+	}
+
+	return nil
+}
+
+// Start runs workers which convert queued jobs until context is cancelled.
+// Running jobs are interrupted & stay queued after cancellation
+func (m *Manager) Start(ctx context.Context, parallelism int) {
+	m.ctx = ctx
+
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	for i := 0; i < parallelism; i++ {
+		m.wg.Add(1)
+
+		go func() {
+			defer m.wg.Done()
+
+			for {
+				jobID, jobCtx, ok := m.next()
+
+				if ok {
+					m.run(jobID, jobCtx)
+					continue
+				}
+
+				select {
+				case <-m.wakeup:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	m.notify()
+}
+
+// Wait blocks until all workers are stopped
+func (m *Manager) Wait() {
+	m.wg.Wait()
+}
+
+// Submit validates batch task & puts it to queue
+func (m *Manager) Submit(batchTask convert.BatchTask) (Job, error) {
+	id, err := newJobID()
+
+	if err != nil {
+		return Job{}, errors.Wrap(err, "Generating job id")
+	}
+
+	job, err := newJob(id, batchTask, time.Now())
+
+	if err != nil {
+		return Job{}, err
+	}
+
+	// journal is required for resuming after restart
+	if job.Task.JournalPath == "" {
+		job.Task.JournalPath = m.journalsPath.BuildFile(job.ID + jobFileExtension).FullPath()
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if err = m.store.Save(*job); err != nil {
+		return Job{}, errors.Wrap(err, "Saving job")
+	}
+
+	m.jobs[job.ID] = job
+	m.pending = append(m.pending, job.ID)
+	m.notify()
+
+	return job.clone(), nil
+}
+
+// Jobs returns all jobs ordered by creation time
+func (m *Manager) Jobs() []Job {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	jobs := make([]Job, 0, len(m.jobs))
+
+	for _, job := range m.jobs {
+		jobs = append(jobs, job.clone())
+	}
+
+	sortJobs(jobs)
+
+	return jobs
+}
+
+// Job returns job by id
+func (m *Manager) Job(id string) (Job, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	job, ok := m.jobs[id]
+
+	if !ok {
+		return Job{}, ErrJobNotFound
+	}
+
+	return job.clone(), nil
+}
+
+// Cancel removes queued job from queue or stops running job.
+// Running job is marked as cancelled after ffmpeg is stopped
+func (m *Manager) Cancel(id string) (Job, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	job, ok := m.jobs[id]
+
+	if !ok {
+		return Job{}, ErrJobNotFound
+	}
+
+	if job.IsFinished() {
+		return job.clone(), ErrJobFinished
+	}
+
+	if cancel, ok := m.cancels[id]; ok {
+		m.cancelled[id] = true
+		cancel()
+
+		return job.clone(), nil
+	}
+
+	for i, pendingID := range m.pending {
+		if pendingID == id {
+			m.pending = append(m.pending[:i], m.pending[i+1:]...)
+			break
+		}
+	}
+
+	job.State = CancelledState
+	now := time.Now()
+	job.FinishedAt = &now
+
+	err := m.store.Save(*job)
+	m.finishSubscriptions(job)
+
+	return job.clone(), errors.Wrap(err, "Saving job")
+}
+
+// Subscribe returns channel with job events & current job state.
+// Channel is closed when job is finished (immediately for finished jobs)
+func (m *Manager) Subscribe(id string) (events chan Event, unsubscribe func(), job Job, err error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	existingJob, ok := m.jobs[id]
+
+	if !ok {
+		return nil, nil, Job{}, ErrJobNotFound
+	}
+
+	events = make(chan Event, subscriberBufferSize)
+
+	if existingJob.IsFinished() {
+		close(events)
+		return events, func() {}, existingJob.clone(), nil
+	}
+
+	m.subscribers[id] = append(m.subscribers[id], events)
+
+	unsubscribe = func() {
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+
+		subscribers := m.subscribers[id]
+
+		for i, subscriber := range subscribers {
+			if subscriber == events {
+				m.subscribers[id] = append(subscribers[:i], subscribers[i+1:]...)
+				close(events)
+				return
+			}
+		}
+	}
+
+	return events, unsubscribe, existingJob.clone(), nil
+}
+
+// next takes job from queue & returns its context. Job is registered as running under the same lock,
+// so Cancel() always finds it either in queue or in running jobs
+func (m *Manager) next() (string, context.Context, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.ctx.Err() != nil || len(m.pending) == 0 {
+		return "", nil, false
+	}
+
+	jobID := m.pending[0]
+	m.pending = m.pending[1:]
+
+	jobCtx, cancel := context.WithCancel(m.ctx)
+	m.cancels[jobID] = cancel
+
+	// other workers should take the rest of queue
+	if len(m.pending) > 0 {
+		m.notify()
+	}
+
+	return jobID, jobCtx, true
+}
+
+func (m *Manager) notify() {
+	select {
+	case m.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// run converts job & saves its state after each task
+func (m *Manager) run(jobID string, jobCtx context.Context) {
+	m.mutex.Lock()
+	job := m.jobs[jobID]
+	cancel := m.cancels[jobID]
+	defer cancel()
+
+	job.State = RunningState
+	now := time.Now()
+	job.StartedAt = &now
+	job.Error = ""
+	batchTask := job.clone().Task
+	m.save(job)
+	m.publish(job, newJobEvent(JobStartedEvent, job))
+	m.mutex.Unlock()
+
+	converter := convert.NewBatchConverter(jobCtx, m.infoGetter)
+
+	startedChan, metadataChan := converter.TaskEvents()
+	progressChan, resultChan, errChan := converter.Convert(batchTask)
+
+	for {
+		select {
+		case msg, ok := <-startedChan:
+			if ok {
+				m.update(job, msg.Task.ID, func(status *TaskStatus) Event {
+					status.State = convert.JournalTaskRunning
+					status.Error = ""
+					return newTaskEvent(TaskStartedEvent, job, msg.Task, msg.Attempt)
+				})
+			}
+
+		case <-metadataChan:
+
+		case msg, ok := <-progressChan:
+			if ok {
+				m.updateProgress(job, msg)
+			}
+
+		case msg, ok := <-resultChan:
+			if ok {
+				m.update(job, msg.Task.ID, func(status *TaskStatus) Event {
+					status.State = convert.JournalTaskDone
+					status.ResultFile = msg.Result.OutFile
+					return newTaskEvent(TaskFinishedEvent, job, msg.Task, msg.Attempt)
+				})
+			}
+
+		case msg, failed := <-errChan:
+			if !failed {
+				m.finish(job)
+				return
+			}
+
+			m.updateFailure(job, msg)
+		}
+	}
+}
+
+func (m *Manager) update(job *Job, taskID string, modify func(status *TaskStatus) Event) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	status := job.taskStatus(taskID)
+
+	if status == nil {
+		return
+	}
+
+	event := modify(status)
+
+	m.save(job)
+	m.publish(job, event)
+}
+
+func (m *Manager) updateProgress(job *Job, msg convert.BatchProgressMessage) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	job.Progress = Progress{
+		Percent: msg.Batch.Percent,
+		Speed:   msg.Batch.Speed,
+		ETA:     msg.Batch.ETA.Seconds(),
+	}
+
+	event := newTaskEvent(ProgressEvent, job, msg.Task, msg.Attempt)
+	event.TaskProgress = msg.Progress.Progress()
+	progress := job.Progress
+	event.Progress = &progress
+
+	m.publish(job, event)
+}
+
+func (m *Manager) updateFailure(job *Job, msg convert.BatchErrorMessage) {
+	// batch error which is not related to any task (i.e. journal can't be opened)
+	if msg.Task.ID == "" {
+		m.mutex.Lock()
+		job.Error = msg.Err.Error()
+		m.mutex.Unlock()
+
+		return
+	}
+
+	m.update(job, msg.Task.ID, func(status *TaskStatus) Event {
+		event := newTaskEvent(TaskFailedEvent, job, msg.Task, msg.Attempt)
+		event.Error = msg.Err.Error()
+		event.WillRetry = msg.WillRetry
+
+		switch {
+		case msg.WillRetry:
+		case msg.IsSkipped():
+			status.State = convert.JournalTaskSkipped
+			event.Type = TaskSkippedEvent
+		case msg.IsInterrupted():
+			status.State = convert.JournalTaskPending
+		default:
+			status.State = convert.JournalTaskFailed
+			status.Error = msg.Err.Error()
+		}
+
+		return event
+	})
+}
+
+// finish sets final job state. Job interrupted by server shutdown is queued again
+func (m *Manager) finish(job *Job) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	delete(m.cancels, job.ID)
+
+	switch {
+	case m.cancelled[job.ID]:
+		delete(m.cancelled, job.ID)
+		job.State = CancelledState
+	case m.ctx.Err() != nil:
+		m.requeue(job)
+		m.save(job)
+		m.finishSubscriptions(job)
+		return
+	case job.Error != "" || hasFailedTasks(job):
+		job.State = FailedState
+	default:
+		job.State = DoneState
+	}
+
+	now := time.Now()
+	job.FinishedAt = &now
+
+	m.save(job)
+	m.finishSubscriptions(job)
+}
+
+// requeue resets job state, so it can be continued from journal
+func (m *Manager) requeue(job *Job) {
+	job.State = QueuedState
+	job.Task.Resume = true
+
+	for i := range job.Tasks {
+		if job.Tasks[i].State == convert.JournalTaskRunning {
+			job.Tasks[i].State = convert.JournalTaskPending
+		}
+	}
+}
+
+// save writes job to store. Error is kept in job, because conversion should not be stopped
+// if state directory is not writable
+func (m *Manager) save(job *Job) {
+	if err := m.store.Save(*job); err != nil {
+		job.Error = errors.Wrap(err, "Saving job").Error()
+	}
+}
+
+// publish sends event to subscribers without blocking conversion
+func (m *Manager) publish(job *Job, event Event) {
+	for _, subscriber := range m.subscribers[job.ID] {
+		select {
+		case subscriber <- event:
+		default:
+		}
+	}
+}
+
+// finishSubscriptions sends last event & closes subscribers channels
+func (m *Manager) finishSubscriptions(job *Job) {
+	m.publish(job, newJobEvent(JobFinishedEvent, job))
+
+	for _, subscriber := range m.subscribers[job.ID] {
+		close(subscriber)
+	}
+
+	delete(m.subscribers, job.ID)
+}
+
+func hasFailedTasks(job *Job) bool {
+	for _, status := range job.Tasks {
+		if status.State == convert.JournalTaskFailed {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sortJobs(jobs []Job) {
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+	})
+}
+
+// newJobID returns sortable unique id: creation time & random suffix
+func newJobID() (string, error) {
+	suffix := make([]byte, 4)
+
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+
+	return time.Now().UTC().Format("20060102T150405") + "-" + hex.EncodeToString(suffix), nil
+}
@@ -0,0 +1,112 @@
+package jobs
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/wailorman/fftb/pkg/files"
+	ffmpegModels "github.com/wailorman/fftb/pkg/goffmpeg/models"
+	"github.com/wailorman/fftb/pkg/media/convert"
+	"github.com/wailorman/fftb/pkg/media/minfo"
+)
+
+// blockingInfoGetter blocks probing until released, so job stays running
+type blockingInfoGetter struct {
+	mutex   sync.Mutex
+	calls   int
+	called  chan struct{}
+	release chan struct{}
+}
+
+func (g *blockingInfoGetter) GetMediaInfo(file files.Filer) (ffmpegModels.Metadata, error) {
+	g.mutex.Lock()
+	g.calls++
+	g.mutex.Unlock()
+
+	select {
+	case g.called <- struct{}{}:
+	default:
+	}
+
+	<-g.release
+
+	return ffmpegModels.Metadata{}, errors.New("ffprobe failed")
+}
+
+func (g *blockingInfoGetter) GetFramesSummary(file files.Filer) (minfo.FramesSummary, error) {
+	return minfo.FramesSummary{}, nil
+}
+
+func (g *blockingInfoGetter) GetFramesList(file files.Filer) (chan bool, chan ffmpegModels.Framer, chan error) {
+	return nil, nil, nil
+}
+
+func Test__Manager__cancelRunning(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpDir, err := ioutil.TempDir("", "fftb_jobs_test")
+	assert.Nil(err)
+	defer os.RemoveAll(tmpDir)
+
+	infoGetter := &blockingInfoGetter{
+		called:  make(chan struct{}, 1),
+		release: make(chan struct{}),
+	}
+
+	manager := NewManager(files.NewPath(tmpDir), infoGetter)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	manager.Start(ctx, 1)
+
+	job, err := manager.Submit(convert.BatchTask{
+		Tasks: []convert.Task{
+			{InFile: "/tmp/fftb_missing_a.mp4", OutFile: "/tmp/fftb_missing_a_out.mp4"},
+			{InFile: "/tmp/fftb_missing_b.mp4", OutFile: "/tmp/fftb_missing_b_out.mp4"},
+		},
+	})
+	assert.Nil(err)
+
+	events, unsubscribe, _, err := manager.Subscribe(job.ID)
+	assert.Nil(err)
+	defer unsubscribe()
+
+	select {
+	case <-infoGetter.called:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Job is not started")
+	}
+
+	runningJob, err := manager.Cancel(job.ID)
+	assert.Nil(err)
+	assert.Equal(RunningState, runningJob.State, "running job is cancelled after conversion is stopped")
+
+	close(infoGetter.release)
+
+	timeout := time.After(5 * time.Second)
+
+	for events != nil {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				events = nil
+			}
+		case <-timeout:
+			t.Fatal("Job is not finished after cancellation")
+		}
+	}
+
+	cancelledJob, err := manager.Job(job.ID)
+	assert.Nil(err)
+	assert.Equal(CancelledState, cancelledJob.State)
+
+	// batch durations are probed before cancellation, tasks are not converted after it
+	assert.Equal(2, infoGetter.calls)
+}
@@ -0,0 +1,107 @@
+package jobs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/wailorman/fftb/pkg/files"
+	"gopkg.in/yaml.v2"
+)
+
+const jobFileExtension = ".yaml"
+
+// Store keeps jobs on disk, one YAML file per job
+type Store struct {
+	path files.Pather
+}
+
+// NewStore _
+func NewStore(path files.Pather) *Store {
+	return &Store{path: path}
+}
+
+// Save writes job to temp file & then moves it to job file,
+// so job file can't be broken by interruption in the middle of writing
+func (s *Store) Save(job Job) error {
+	d, err := yaml.Marshal(&job)
+
+	if err != nil {
+		return errors.Wrap(err, "Exporting job to YAML")
+	}
+
+	jobFile := s.path.BuildFile(job.ID + jobFileExtension)
+	tmpFile := jobFile.NewWithSuffix("_tmp")
+
+	err = tmpFile.Create()
+
+	if err != nil {
+		return errors.Wrap(err, "Creating temp job file")
+	}
+
+	writer, err := tmpFile.WriteContent()
+
+	if err != nil {
+		return errors.Wrap(err, "Building temp job file writer")
+	}
+
+	_, err = writer.Write(d)
+	writer.Close()
+
+	if err != nil {
+		return errors.Wrap(err, "Writing temp job file")
+	}
+
+	err = tmpFile.Move(jobFile.FullPath())
+
+	if err != nil {
+		return errors.Wrap(err, "Moving temp job file")
+	}
+
+	return nil
+}
+
+// LoadAll reads all saved jobs ordered by creation time. Missing store directory is not an error
+func (s *Store) LoadAll() ([]Job, error) {
+	entries, err := ioutil.ReadDir(s.path.FullPath())
+
+	if os.IsNotExist(err) {
+		return []Job{}, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "Reading jobs directory")
+	}
+
+	jobs := make([]Job, 0, len(entries))
+
+	for _, entry := range entries {
+		name := entry.Name()
+
+		if entry.IsDir() || filepath.Ext(name) != jobFileExtension || strings.HasSuffix(name, "_tmp"+jobFileExtension) {
+			continue
+		}
+
+		content, err := s.path.BuildFile(name).ReadAllContent()
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "Reading job file `%s`", name)
+		}
+
+		var job Job
+
+		err = yaml.Unmarshal([]byte(content), &job)
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "Parsing job file `%s`", name)
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	sortJobs(jobs)
+
+	return jobs, nil
+}
@@ -150,7 +150,12 @@ func (c *Instance) Start() (progress chan ff.Progressable, failures chan error)
 					return
 				}
 
+				// partially written segments are useless
+				<-c.segmenter.Closed()
+				c.segmenter.Purge()
+
 				failures <- failure
+				return
 			}
 		}
 	}()
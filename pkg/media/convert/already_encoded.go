@@ -0,0 +1,87 @@
+package convert
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+	ffmpegModels "github.com/wailorman/fftb/pkg/goffmpeg/models"
+	mediaUtils "github.com/wailorman/fftb/pkg/media/utils"
+)
+
+const (
+	// ReencodeAlreadyEncodedPolicy converts file as usual (default)
+	ReencodeAlreadyEncodedPolicy = "reencode"
+	// SkipAlreadyEncodedPolicy skips conversion of the file
+	SkipAlreadyEncodedPolicy = "skip"
+	// RemuxAlreadyEncodedPolicy copies video stream to output file without encoding
+	RemuxAlreadyEncodedPolicy = "remux"
+)
+
+// alreadyEncodedAction returns policy which should be applied to the task & the reason why input file
+// is treated as already encoded. Returns ReencodeAlreadyEncodedPolicy if file should be converted as usual
+func alreadyEncodedAction(task Task, metadata ffmpegModels.Metadata) (policy string, reason string, err error) {
+	policy = task.Params.AlreadyEncodedPolicy
+
+	switch policy {
+	case "", ReencodeAlreadyEncodedPolicy:
+		return ReencodeAlreadyEncodedPolicy, "", nil
+	case SkipAlreadyEncodedPolicy, RemuxAlreadyEncodedPolicy:
+	default:
+		return "", "", ErrUnsupportedAlreadyEncodedPolicy
+	}
+
+	stream, ok := mediaUtils.FindVideoStream(metadata)
+
+	if !ok {
+		return ReencodeAlreadyEncodedPolicy, "", nil
+	}
+
+	if stream.CodecName == task.Params.VideoCodec {
+		return policy, fmt.Sprintf("Input video is already encoded with %s", stream.CodecName), nil
+	}
+
+	if !task.Params.CompareBitRate || task.Params.VideoBitRate == "" {
+		return ReencodeAlreadyEncodedPolicy, "", nil
+	}
+
+	requestedBitRate, err := parseBitRate(task.Params.VideoBitRate)
+
+	if err != nil {
+		return "", "", errors.Wrap(err, "Parsing video bitrate")
+	}
+
+	inputBitRate, err := strconv.ParseInt(stream.BitRate, 10, 64)
+
+	if err != nil || inputBitRate == 0 {
+		return ReencodeAlreadyEncodedPolicy, "", nil
+	}
+
+	if inputBitRate <= requestedBitRate {
+		return policy, fmt.Sprintf("Input video bitrate (%dk) is not higher than requested one", inputBitRate/1000), nil
+	}
+
+	return ReencodeAlreadyEncodedPolicy, "", nil
+}
+
+// configureRemux copies video stream as is. Audio & streams mapping params are still applied
+func configureRemux(task Task, metadata ffmpegModels.Metadata, mediaFile *ffmpegModels.Mediafile) error {
+	mediaFile.SetHideBanner(true)
+	mediaFile.SetVideoCodec("copy")
+	mediaFile.SetMaxMuxingQueueSize(102400)
+
+	if stream, ok := mediaUtils.FindVideoStream(metadata); ok && stream.CodecName == HevcCodecType {
+		// same as HevcCodec does for Apple devices compatibility
+		mediaFile.SetVideoTag("hvc1")
+	}
+
+	if err := newAudioTranscoding(task).configure(mediaFile); err != nil {
+		return errors.Wrap(err, "Configuring audio")
+	}
+
+	if err := newStreamMapping(task, metadata).configure(mediaFile); err != nil {
+		return errors.Wrap(err, "Configuring streams mapping")
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test__alreadyEncodedAction(t *testing.T) {
+	assert := assert.New(t)
+
+	testTable := []struct {
+		inputCodec     string
+		params         Params
+		expectedPolicy string
+		expectedErr    error
+	}{
+		{
+			inputCodec:     "hevc",
+			params:         Params{VideoCodec: HevcCodecType},
+			expectedPolicy: ReencodeAlreadyEncodedPolicy,
+		},
+		{
+			inputCodec:     "hevc",
+			params:         Params{VideoCodec: HevcCodecType, AlreadyEncodedPolicy: SkipAlreadyEncodedPolicy},
+			expectedPolicy: SkipAlreadyEncodedPolicy,
+		},
+		{
+			inputCodec:     "h264",
+			params:         Params{VideoCodec: HevcCodecType, AlreadyEncodedPolicy: SkipAlreadyEncodedPolicy},
+			expectedPolicy: ReencodeAlreadyEncodedPolicy,
+		},
+		{
+			inputCodec:     "h264",
+			params:         Params{VideoCodec: HevcCodecType, AlreadyEncodedPolicy: RemuxAlreadyEncodedPolicy, VideoBitRate: "25M", CompareBitRate: true},
+			expectedPolicy: RemuxAlreadyEncodedPolicy,
+		},
+		{
+			inputCodec:     "h264",
+			params:         Params{VideoCodec: HevcCodecType, AlreadyEncodedPolicy: RemuxAlreadyEncodedPolicy, VideoBitRate: "15M", CompareBitRate: true},
+			expectedPolicy: ReencodeAlreadyEncodedPolicy,
+		},
+		{
+			inputCodec:  "hevc",
+			params:      Params{VideoCodec: HevcCodecType, AlreadyEncodedPolicy: "delete"},
+			expectedErr: ErrUnsupportedAlreadyEncodedPolicy,
+		},
+	}
+
+	for i, testItem := range testTable {
+		// test metadata has 20 mbit/s video stream
+		policy, _, err := alreadyEncodedAction(Task{Params: testItem.params}, newTestVideoMetadata(testItem.inputCodec))
+
+		if testItem.expectedErr != nil {
+			assert.Equal(testItem.expectedErr, errors.Cause(err), i)
+			continue
+		}
+
+		assert.Nil(err, i)
+		assert.Equal(testItem.expectedPolicy, policy, i)
+	}
+}
+
+func Test__configureRemux(t *testing.T) {
+	assert := assert.New(t)
+
+	mediaFile := newTestMediaFile()
+	task := Task{Params: Params{VideoCodec: HevcCodecType, Scale: FixedHalfScaleType, AudioCodec: "aac"}}
+
+	assert.Nil(configureRemux(task, newTestVideoMetadata("hevc"), mediaFile))
+	assert.Equal([]string{
+		"-i", "in.mp4", "-hide_banner", "-c:v", "copy", "-c:a", "aac", "-max_muxing_queue_size", "102400",
+		"-tag:v", "hvc1", "out.mp4",
+	}, mediaFile.ToStrCommand())
+}
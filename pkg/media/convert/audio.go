@@ -0,0 +1,61 @@
+package convert
+
+import (
+	ffmpegModels "github.com/wailorman/fftb/pkg/goffmpeg/models"
+)
+
+// CopyAudioCodecType _
+const CopyAudioCodecType = "copy"
+
+// audioEncoders maps audio codec names to ffmpeg encoders
+var audioEncoders = map[string]string{
+	"aac":        "aac",
+	"opus":       "libopus",
+	"libopus":    "libopus",
+	"mp3":        "libmp3lame",
+	"libmp3lame": "libmp3lame",
+	"ac3":        "ac3",
+	"flac":       "flac",
+}
+
+type audioTranscoding struct {
+	task Task
+}
+
+func newAudioTranscoding(task Task) *audioTranscoding {
+	return &audioTranscoding{
+		task: task,
+	}
+}
+
+func (at *audioTranscoding) configure(mediaFile *ffmpegModels.Mediafile) error {
+	params := at.task.Params
+
+	if params.DropAudio {
+		mediaFile.SetSkipAudio(true)
+		return nil
+	}
+
+	if params.AudioCodec == "" || params.AudioCodec == CopyAudioCodecType {
+		// stream copying does not allow to change audio parameters
+		if params.AudioBitRate != "" || params.AudioChannels > 0 || params.AudioSampleRate > 0 {
+			return ErrAudioParamsRequireEncoding
+		}
+
+		mediaFile.SetAudioCodec(CopyAudioCodecType)
+		return nil
+	}
+
+	encoder, ok := audioEncoders[params.AudioCodec]
+
+	if !ok {
+		return ErrUnsupportedAudioCodec
+	}
+
+	mediaFile.SetAudioCodec(encoder)
+	mediaFile.SetAudioBitRate(params.AudioBitRate)
+	mediaFile.SetAudioChannels(params.AudioChannels)
+	mediaFile.SetAudioRate(params.AudioSampleRate)
+
+	return nil
+}
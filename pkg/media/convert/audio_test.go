@@ -0,0 +1,53 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test__audioTranscoding__configure(t *testing.T) {
+	assert := assert.New(t)
+
+	testTable := []struct {
+		params          Params
+		expectedCommand []string
+		expectedErr     error
+	}{
+		{
+			params:          Params{},
+			expectedCommand: []string{"-i", "in.mp4", "-c:a", "copy", "out.mp4"},
+		},
+		{
+			params:          Params{AudioCodec: "opus", AudioBitRate: "128k", AudioChannels: 2, AudioSampleRate: 48000},
+			expectedCommand: []string{"-i", "in.mp4", "-ar", "48000", "-c:a", "libopus", "-b:a", "128k", "-ac", "2", "out.mp4"},
+		},
+		{
+			params:          Params{AudioCodec: "aac", DropAudio: true},
+			expectedCommand: []string{"-i", "in.mp4", "-an", "out.mp4"},
+		},
+		{
+			params:      Params{AudioBitRate: "128k"},
+			expectedErr: ErrAudioParamsRequireEncoding,
+		},
+		{
+			params:      Params{AudioCodec: "wma"},
+			expectedErr: ErrUnsupportedAudioCodec,
+		},
+	}
+
+	for i, testItem := range testTable {
+		mediaFile := newTestMediaFile()
+
+		err := newAudioTranscoding(Task{Params: testItem.params}).configure(mediaFile)
+
+		if testItem.expectedErr != nil {
+			assert.Equal(testItem.expectedErr, errors.Cause(err), i)
+			continue
+		}
+
+		assert.Nil(err, i)
+		assert.Equal(testItem.expectedCommand, mediaFile.ToStrCommand(), i)
+	}
+}
@@ -0,0 +1,154 @@
+package convert
+
+import (
+	"strconv"
+
+	"github.com/pkg/errors"
+	ffmpegModels "github.com/wailorman/fftb/pkg/goffmpeg/models"
+)
+
+const (
+	// SvtAv1EncoderType _
+	SvtAv1EncoderType = "libsvtav1"
+	// AomAv1EncoderType _
+	AomAv1EncoderType = "libaom-av1"
+)
+
+// svtAv1Presets maps x264-like preset names to SVT-AV1 numeric presets (0 - slowest, 13 - fastest)
+var svtAv1Presets = map[string]int{
+	"ultrafast": 12,
+	"superfast": 11,
+	"veryfast":  10,
+	"faster":    9,
+	"fast":      8,
+	"medium":    6,
+	"slow":      5,
+	"slower":    4,
+	"veryslow":  3,
+}
+
+// aomAv1CPUUsed maps x264-like preset names to libaom -cpu-used values (0 - slowest, 8 - fastest)
+var aomAv1CPUUsed = map[string]int{
+	"ultrafast": 8,
+	"superfast": 7,
+	"veryfast":  6,
+	"faster":    5,
+	"fast":      5,
+	"medium":    4,
+	"slow":      3,
+	"slower":    2,
+	"veryslow":  1,
+}
+
+// Av1Codec _
+type Av1Codec struct {
+	task     Task
+	metadata ffmpegModels.Metadata
+}
+
+// NewAv1Codec _
+func NewAv1Codec(task Task, metadata ffmpegModels.Metadata) *Av1Codec {
+	return &Av1Codec{
+		task:     task,
+		metadata: metadata,
+	}
+}
+
+func (c *Av1Codec) configure(mediaFile *ffmpegModels.Mediafile) error {
+	var err error
+
+	mediaFile.SetHideBanner(true)
+	mediaFile.SetVsync(true)
+	mediaFile.SetMaxMuxingQueueSize(102400)
+
+	hwaccel := chooseHwAccel(c.task, c.metadata)
+
+	if _, ok := hwaccel.(*emptyHwAccel); ok {
+		if err = c.configureEncoder(mediaFile); err != nil {
+			return err
+		}
+	} else if c.task.Params.VideoQuality == 0 {
+		// hardware encoder, its preset & quality are configured by hwaccel
+		mediaFile.SetVideoBitRate(c.task.Params.VideoBitRate)
+	}
+
+	mediaFile.SetKeyframeInterval(c.task.Params.KeyframeInterval)
+
+	if err = hwaccel.configure(mediaFile); err != nil {
+		return errors.Wrap(err, "Configuring hwaccel")
+	}
+
+	return nil
+}
+
+// configureEncoder sets CPU encoder with its preset & quality options
+func (c *Av1Codec) configureEncoder(mediaFile *ffmpegModels.Mediafile) error {
+	switch c.task.Params.VideoEncoder {
+	case "", SvtAv1EncoderType:
+		mediaFile.SetVideoCodec(SvtAv1EncoderType)
+
+		preset, err := mapAv1Preset(c.task.Params.Preset, svtAv1Presets)
+
+		if err != nil {
+			return err
+		}
+
+		mediaFile.SetPreset(preset)
+
+		if c.task.Params.VideoQuality > 0 {
+			mediaFile.SetCRF(uint32(c.task.Params.VideoQuality))
+		} else {
+			mediaFile.SetVideoBitRate(c.task.Params.VideoBitRate)
+		}
+
+	case AomAv1EncoderType:
+		mediaFile.SetVideoCodec(AomAv1EncoderType)
+
+		cpuUsed, err := mapAv1Preset(c.task.Params.Preset, aomAv1CPUUsed)
+
+		if err != nil {
+			return err
+		}
+
+		if cpuUsed != "" {
+			value, _ := strconv.Atoi(cpuUsed)
+			mediaFile.SetCPUUsed(value)
+		}
+
+		if c.task.Params.VideoQuality > 0 {
+			// libaom requires zero bitrate for constant quality mode
+			mediaFile.SetCRF(uint32(c.task.Params.VideoQuality))
+			mediaFile.SetVideoBitRate("0")
+		} else {
+			mediaFile.SetVideoBitRate(c.task.Params.VideoBitRate)
+		}
+
+	default:
+		return ErrCodecIsNotSupportedByEncoder
+	}
+
+	return nil
+}
+
+func (c *Av1Codec) getType() string {
+	return Av1CodecType
+}
+
+// mapAv1Preset returns numeric preset value. Numeric presets are passed as is
+func mapAv1Preset(preset string, presets map[string]int) (string, error) {
+	if preset == "" {
+		return "", nil
+	}
+
+	if _, err := strconv.Atoi(preset); err == nil {
+		return preset, nil
+	}
+
+	value, ok := presets[preset]
+
+	if !ok {
+		return "", ErrUnsupportedPreset
+	}
+
+	return strconv.Itoa(value), nil
+}
@@ -0,0 +1,121 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	ffmpegModels "github.com/wailorman/fftb/pkg/goffmpeg/models"
+)
+
+func newTestMediaFile() *ffmpegModels.Mediafile {
+	mediaFile := new(ffmpegModels.Mediafile)
+	mediaFile.SetInputPath("in.mp4")
+	mediaFile.SetOutputPath("out.mp4")
+
+	return mediaFile
+}
+
+func newTestVideoMetadata(codecName string) ffmpegModels.Metadata {
+	return ffmpegModels.Metadata{
+		Streams: []ffmpegModels.Streams{
+			{
+				Index:     0,
+				CodecType: "video",
+				CodecName: codecName,
+				Width:     1920,
+				Height:    1080,
+				BitRate:   "20000000",
+			},
+		},
+	}
+}
+
+func Test__Av1Codec__configure(t *testing.T) {
+	assert := assert.New(t)
+
+	testTable := []struct {
+		params          Params
+		expectedCommand []string
+		expectedErr     error
+	}{
+		{
+			params: Params{VideoCodec: Av1CodecType, VideoQuality: 30, Preset: "slow", KeyframeInterval: 120},
+			expectedCommand: []string{
+				"-vsync", "0", "-i", "in.mp4", "-hide_banner", "-c:v", "libsvtav1", "-c:a", "copy",
+				"-crf", "30", "-max_muxing_queue_size", "102400", "-g", "120", "-preset", "5", "out.mp4",
+			},
+		},
+		{
+			params: Params{VideoCodec: Av1CodecType, VideoBitRate: "8M", Preset: "8"},
+			expectedCommand: []string{
+				"-vsync", "0", "-i", "in.mp4", "-hide_banner", "-c:v", "libsvtav1", "-b:v", "8M", "-c:a", "copy",
+				"-max_muxing_queue_size", "102400", "-preset", "8", "out.mp4",
+			},
+		},
+		{
+			params: Params{VideoCodec: Av1CodecType, VideoEncoder: AomAv1EncoderType, VideoQuality: 30, Preset: "medium"},
+			expectedCommand: []string{
+				"-vsync", "0", "-i", "in.mp4", "-hide_banner", "-c:v", "libaom-av1", "-b:v", "0", "-c:a", "copy",
+				"-crf", "30", "-max_muxing_queue_size", "102400", "-cpu-used", "4", "out.mp4",
+			},
+		},
+		{
+			params: Params{VideoCodec: Av1CodecType, HWAccel: NvencHWAccelType, VideoQuality: 30, Preset: "slow"},
+			expectedCommand: []string{
+				"-hwaccel", "cuvid", "-vsync", "0", "-c:v", "h264_cuvid", "-i", "in.mp4", "-hide_banner",
+				"-c:v", "av1_nvenc", "-c:a", "copy", "-rc", "constqp", "-max_muxing_queue_size", "102400",
+				"-qp", "30", "-preset", "slow", "out.mp4",
+			},
+		},
+		{
+			// NVENC presets are passed as is, CPU encoder quality options are not used
+			params: Params{VideoCodec: Av1CodecType, HWAccel: NvencHWAccelType, VideoQuality: 30, Preset: "p4"},
+			expectedCommand: []string{
+				"-hwaccel", "cuvid", "-vsync", "0", "-c:v", "h264_cuvid", "-i", "in.mp4", "-hide_banner",
+				"-c:v", "av1_nvenc", "-c:a", "copy", "-rc", "constqp", "-max_muxing_queue_size", "102400",
+				"-qp", "30", "-preset", "p4", "out.mp4",
+			},
+		},
+		{
+			params: Params{VideoCodec: Av1CodecType, HWAccel: NvencHWAccelType, VideoBitRate: "8M", Preset: "hq"},
+			expectedCommand: []string{
+				"-hwaccel", "cuvid", "-vsync", "0", "-c:v", "h264_cuvid", "-i", "in.mp4", "-hide_banner",
+				"-c:v", "av1_nvenc", "-b:v", "8M", "-c:a", "copy", "-rc", "constqp", "-max_muxing_queue_size", "102400",
+				"-preset", "hq", "out.mp4",
+			},
+		},
+		{
+			params:      Params{VideoCodec: Av1CodecType, Preset: "hq"},
+			expectedErr: ErrUnsupportedPreset,
+		},
+		{
+			params:      Params{VideoCodec: Av1CodecType, VideoEncoder: "libx264"},
+			expectedErr: ErrCodecIsNotSupportedByEncoder,
+		},
+		{
+			params:      Params{VideoCodec: Av1CodecType, HWAccel: VTBHWAccelType, VideoBitRate: "8M"},
+			expectedErr: ErrCodecIsNotSupportedByEncoder,
+		},
+	}
+
+	for i, testItem := range testTable {
+		task := Task{Params: testItem.params}
+		metadata := newTestVideoMetadata("h264")
+		mediaFile := newTestMediaFile()
+
+		codec, err := chooseCodec(task, metadata)
+		assert.Nil(err, i)
+
+		err = codec.configure(mediaFile)
+
+		if testItem.expectedErr != nil {
+			assert.Equal(testItem.expectedErr, errors.Cause(err), i)
+			continue
+		}
+
+		assert.Nil(err, i)
+		assert.Nil(newAudioTranscoding(task).configure(mediaFile), i)
+		assert.Equal(testItem.expectedCommand, mediaFile.ToStrCommand(), i)
+	}
+}
@@ -3,8 +3,13 @@ package convert
 import (
 	"context"
 	"strconv"
+	"sync"
+	"time"
 
+	"github.com/pkg/errors"
 	"github.com/wailorman/fftb/pkg/chwg"
+	"github.com/wailorman/fftb/pkg/files"
+	"github.com/wailorman/fftb/pkg/media/ff"
 	"github.com/wailorman/fftb/pkg/media/minfo"
 )
 
@@ -13,79 +18,471 @@ type BatchConverter struct {
 	wg         *chwg.ChannelledWaitGroup
 	ctx        context.Context
 	infoGetter minfo.Getter
+	journal    *Journal
+
+	outputsMutex    sync.Mutex
+	reservedOutputs map[string]bool
+
+	taskStarted      chan BatchTaskStartedMessage
+	metadataReceived chan MetadataReceivedBatchMessage
+
+	progressTracker *batchProgressTracker
+	tasksCount      int
 }
 
 // NewBatchConverter _
 func NewBatchConverter(ctx context.Context, infoGetter minfo.Getter) *BatchConverter {
 	return &BatchConverter{
-		wg:         chwg.New(),
-		ctx:        ctx,
-		infoGetter: infoGetter,
+		wg:              chwg.New(),
+		ctx:             ctx,
+		infoGetter:      infoGetter,
+		reservedOutputs: make(map[string]bool),
 	}
 }
 
+// TaskEvents returns channels which receive task started & input metadata messages.
+// Should be called before Convert(). Channels are closed together with Convert() channels
+func (bc *BatchConverter) TaskEvents() (
+	started chan BatchTaskStartedMessage,
+	metadata chan MetadataReceivedBatchMessage,
+) {
+	bc.taskStarted = make(chan BatchTaskStartedMessage)
+	bc.metadataReceived = make(chan MetadataReceivedBatchMessage)
+
+	return bc.taskStarted, bc.metadataReceived
+}
+
 // Convert _
 func (bc *BatchConverter) Convert(batchTask BatchTask) (
 	progress chan BatchProgressMessage,
+	results chan BatchResultMessage,
 	failures chan BatchErrorMessage,
 ) {
-	taskCount := len(batchTask.Tasks)
+	err := bc.openJournal(batchTask)
 
-	progress = make(chan BatchProgressMessage)
-	failures = make(chan BatchErrorMessage)
-	taskQueue := make(chan Task, taskCount)
+	if err != nil {
+		return bc.failToStart(err)
+	}
+
+	tasks := make([]Task, 0, len(batchTask.Tasks))
+
+	for i, task := range batchTask.Tasks {
+		if task.ID == "" {
+			task.ID = strconv.Itoa(i)
+		}
+
+		if batchTask.Resume && bc.journal != nil && bc.journal.IsDone(task) {
+			continue
+		}
+
+		tasks = append(tasks, task)
+	}
+
+	bc.tasksCount = len(tasks)
+	bc.progressTracker = newBatchProgressTracker(bc.getDurations(tasks), time.Now)
+
+	queue := make(chan Task, len(tasks))
+
+	for _, task := range tasks {
+		queue <- task
+	}
+
+	close(queue)
 
-	bc.wg.Add(taskCount)
+	return bc.run(batchTask, queue)
+}
+
+// TasksCount returns number of tasks queued by Convert().
+// Tasks which were converted in previous runs (in resume mode) are not counted
+func (bc *BatchConverter) TasksCount() int {
+	return bc.tasksCount
+}
+
+// ConvertQueue converts tasks received from queue until it's closed, so one converter
+// can process files which appear over time (i.e. in watched directory). batchTask.Tasks are ignored.
+// In resume mode tasks which were converted or skipped in previous runs are not converted again.
+// Tasks without ID receive input file path as ID
+func (bc *BatchConverter) ConvertQueue(batchTask BatchTask, queue <-chan Task) (
+	progress chan BatchProgressMessage,
+	results chan BatchResultMessage,
+	failures chan BatchErrorMessage,
+) {
+	err := bc.openJournal(batchTask)
+
+	if err != nil {
+		return bc.failToStart(err)
+	}
+
+	bc.progressTracker = newBatchProgressTracker(make(map[string]float64), time.Now)
+
+	acceptedQueue := make(chan Task)
 
 	go func() {
-		for i := 0; i < batchTask.Parallelism; i++ {
-			go func() {
-				for task := range taskQueue {
-					if !bc.wg.IsFinished() {
-						err := bc.convertOne(task, progress)
-
-						if err != nil {
-							failures <- BatchErrorMessage{
-								Task: task,
-								Err:  err,
-							}
-
-							if batchTask.StopConversionOnError {
-								bc.wg.AllDone()
-								return
-							}
-						}
-
-						bc.wg.Done()
-					}
-				}
-			}()
+		defer close(acceptedQueue)
+
+		for task := range queue {
+			if task.ID == "" {
+				task.ID = task.InFile
+			}
+
+			if batchTask.Resume && bc.journal != nil && bc.journal.IsProcessed(task) {
+				continue
+			}
+
+			bc.progressTracker.add(bc.getDurations([]Task{task}))
+
+			acceptedQueue <- task
 		}
 	}()
 
+	return bc.run(batchTask, acceptedQueue)
+}
+
+// failToStart reports batch error & closes all channels
+func (bc *BatchConverter) failToStart(err error) (
+	progress chan BatchProgressMessage,
+	results chan BatchResultMessage,
+	failures chan BatchErrorMessage,
+) {
+	progress = make(chan BatchProgressMessage)
+	results = make(chan BatchResultMessage)
+	failures = make(chan BatchErrorMessage)
+
 	go func() {
-		for i, task := range batchTask.Tasks {
-			if task.ID == "" {
-				task.ID = strconv.Itoa(i)
+		failures <- BatchErrorMessage{Err: errors.Wrap(err, "Opening journal")}
+		close(progress)
+		close(results)
+		close(failures)
+		bc.closeTaskEvents()
+	}()
+
+	return progress, results, failures
+}
+
+// run converts tasks from queue by parallel workers. Channels are closed when queue is closed & all tasks are finished
+func (bc *BatchConverter) run(batchTask BatchTask, queue <-chan Task) (
+	progress chan BatchProgressMessage,
+	results chan BatchResultMessage,
+	failures chan BatchErrorMessage,
+) {
+	progress = make(chan BatchProgressMessage)
+	results = make(chan BatchResultMessage)
+	failures = make(chan BatchErrorMessage)
+
+	// queue capacity is kept, so all tasks of regular batch are marked as pending at once
+	taskQueue := make(chan Task, cap(queue))
+
+	// stopped is closed when conversion is stopped because of error (see StopConversionOnError)
+	stopped := make(chan struct{})
+	stopOnce := &sync.Once{}
+
+	// queue reader is counted as a task, so batch is not finished while new tasks can be received
+	bc.wg.Add(1)
+
+	for i := 0; i < batchTask.Parallelism; i++ {
+		go func() {
+			for task := range taskQueue {
+				// tasks are not started after interruption or error
+				if isClosed(stopped) || bc.ctx.Err() != nil {
+					bc.wg.Done()
+					continue
+				}
+
+				err := bc.runTask(batchTask, task, progress, results, failures)
+
+				if err != nil && errors.Cause(err) != ErrTaskSkipped && batchTask.StopConversionOnError {
+					stopOnce.Do(func() { close(stopped) })
+				}
+
+				bc.wg.Done()
 			}
+		}()
+	}
+
+	go func() {
+		defer close(taskQueue)
+
+		for {
+			select {
+			case task, ok := <-queue:
+				if !ok {
+					bc.wg.Done()
+					return
+				}
+
+				bc.wg.Add(1)
+
+				bc.reportJournalError(task, failures, bc.updateJournal(func(j *Journal) error {
+					return j.MarkPending(task)
+				}))
 
-			taskQueue <- task
+				select {
+				case taskQueue <- task:
+				case <-stopped:
+					bc.wg.Done()
+				case <-bc.ctx.Done():
+					bc.wg.Done()
+				}
+
+			case <-stopped:
+				bc.wg.Done()
+
+				// rest of tasks are dropped, so queue writer is not blocked
+				for range queue {
+				}
+
+				return
+			}
 		}
 	}()
 
 	go func() {
 		bc.wg.Wait()
 		close(progress)
+		close(results)
 		close(failures)
-		close(taskQueue)
+		bc.closeTaskEvents()
 	}()
 
-	return progress, failures
+	return progress, results, failures
+}
+
+func isClosed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func (bc *BatchConverter) closeTaskEvents() {
+	if bc.taskStarted != nil {
+		close(bc.taskStarted)
+		close(bc.metadataReceived)
+	}
+}
+
+// notifyTaskStarted sends task events if they were requested by TaskEvents()
+func (bc *BatchConverter) notifyTaskStarted(task Task, attempt int) {
+	if bc.taskStarted == nil {
+		return
+	}
+
+	bc.taskStarted <- BatchTaskStartedMessage{Task: task, Attempt: attempt}
+
+	if attempt > 1 {
+		return
+	}
+
+	// infoGetter is usually cached, so file will not be probed again by converter
+	metadata, err := bc.infoGetter.GetMediaInfo(files.NewFile(task.InFile))
+
+	if err == nil {
+		bc.metadataReceived <- MetadataReceivedBatchMessage{Metadata: metadata, Task: task}
+	}
+}
+
+// getDurations returns input media durations by task id.
+// Duration of task is 0 if it can't be received
+func (bc *BatchConverter) getDurations(tasks []Task) map[string]float64 {
+	durations := make(map[string]float64)
+
+	for _, task := range tasks {
+		metadata, err := bc.infoGetter.GetMediaInfo(files.NewFile(task.InFile))
+
+		if err == nil {
+			durations[task.ID] = metadata.DurationSeconds()
+		}
+	}
+
+	return durations
+}
+
+func (bc *BatchConverter) openJournal(batchTask BatchTask) error {
+	if batchTask.JournalPath == "" {
+		return nil
+	}
+
+	bc.journal = NewJournal(files.NewFile(batchTask.JournalPath))
+
+	return bc.journal.Load()
+}
+
+func (bc *BatchConverter) updateJournal(update func(j *Journal) error) error {
+	if bc.journal == nil {
+		return nil
+	}
+
+	return update(bc.journal)
 }
 
-func (bc *BatchConverter) convertOne(task Task, progress chan BatchProgressMessage) error {
-	sCtx, sCancel := context.WithCancel(bc.ctx)
+func (bc *BatchConverter) reportJournalError(task Task, failures chan BatchErrorMessage, err error) {
+	if err != nil {
+		failures <- BatchErrorMessage{
+			Task: task,
+			Err:  errors.Wrap(err, "Updating journal"),
+		}
+	}
+}
+
+func (bc *BatchConverter) runTask(
+	batchTask BatchTask,
+	task Task,
+	progress chan BatchProgressMessage,
+	results chan BatchResultMessage,
+	failures chan BatchErrorMessage,
+) error {
+	bc.reportJournalError(task, failures, bc.updateJournal(func(j *Journal) error {
+		return j.MarkRunning(task)
+	}))
+
+	var result TaskResult
+	attempt := 1
+
+	resolvedTask, err := bc.reserveOutput(task)
+
+	if err == nil {
+		result, attempt, err = bc.convertWithRetries(batchTask, resolvedTask, progress, failures)
+	}
+
+	if err == nil {
+		bc.progressTracker.finish(task.ID)
+	} else {
+		bc.progressTracker.drop(task.ID)
+	}
+
+	bc.reportJournalError(task, failures, bc.updateJournal(func(j *Journal) error {
+		switch {
+		case err == nil:
+			return j.MarkDone(task, result)
+		case errors.Cause(err) == ErrTaskSkipped:
+			return j.MarkSkipped(task)
+		default:
+			return j.MarkFailed(task, err)
+		}
+	}))
+
+	if err != nil {
+		failures <- BatchErrorMessage{
+			Task:    task,
+			Err:     err,
+			Attempt: attempt,
+		}
+
+		return err
+	}
+
+	results <- BatchResultMessage{
+		Task:    task,
+		Result:  result,
+		Attempt: attempt,
+	}
+
+	return nil
+}
+
+// convertWithRetries converts task until it succeeds or retry policy attempts are exhausted.
+// Failed attempts which will be retried are sent to failures channel.
+// Returns result, number of the last attempt & its error
+func (bc *BatchConverter) convertWithRetries(
+	batchTask BatchTask,
+	task Task,
+	progress chan BatchProgressMessage,
+	failures chan BatchErrorMessage,
+) (TaskResult, int, error) {
+	policy := batchTask.Retry
+	backoff := policy.Backoff
+
+	for attempt := 1; ; attempt++ {
+		result, err := bc.convertOne(batchTask, task, attempt, progress)
+
+		if err == nil || attempt >= policy.MaxAttempts || !isRetryable(err) || bc.ctx.Err() != nil {
+			return result, attempt, err
+		}
+
+		failures <- BatchErrorMessage{
+			Task:      task,
+			Err:       err,
+			Attempt:   attempt,
+			WillRetry: true,
+		}
+
+		if policy.HWAccelFallback {
+			task.Params.HWAccel = ""
+			task.Params.HWDevice = ""
+		}
+
+		select {
+		case <-bc.ctx.Done():
+			return TaskResult{}, attempt, errors.Wrap(ErrInterrupted, err.Error())
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+	}
+}
+
+// isRetryable returns true for transient errors: ffmpeg failure, stall or task timeout.
+// Other errors (invalid params, unsupported input, etc.) will happen again on next attempt
+func isRetryable(err error) bool {
+	switch errors.Cause(err) {
+	case ff.ErrProcessFailed, ff.ErrProcessTimeout, ErrTaskTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// reserveOutput resolves output conflict taking into account outputs of other tasks in batch
+func (bc *BatchConverter) reserveOutput(task Task) (Task, error) {
+	// each task replaces its own input file
+	if task.Params.ReplaceOriginal {
+		return task, nil
+	}
+
+	bc.outputsMutex.Lock()
+	defer bc.outputsMutex.Unlock()
+
+	resolvedTask, err := resolveOutputConflict(task, func(file files.Filer) bool {
+		return bc.reservedOutputs[file.FullPath()] || isOutputTaken(file)
+	})
+
+	if err != nil {
+		return task, err
+	}
+
+	bc.reservedOutputs[resolvedTask.OutFile] = true
+
+	// conflict is already resolved, so converter should write to chosen file
+	resolvedTask.Params.OutputConflictPolicy = OverwriteOutputConflictPolicy
+
+	return resolvedTask, nil
+}
+
+func (bc *BatchConverter) convertOne(
+	batchTask BatchTask,
+	task Task,
+	attempt int,
+	progress chan BatchProgressMessage,
+) (TaskResult, error) {
+	var sCtx context.Context
+	var sCancel func()
+
+	if batchTask.TaskTimeout > 0 {
+		sCtx, sCancel = context.WithTimeout(bc.ctx, batchTask.TaskTimeout)
+	} else {
+		sCtx, sCancel = context.WithCancel(bc.ctx)
+	}
+
+	bc.notifyTaskStarted(task, attempt)
+
 	sConv := NewConverter(sCtx, bc.infoGetter)
+
+	if batchTask.StallTimeout > 0 {
+		sConv.SetStallTimeout(batchTask.StallTimeout)
+	}
+
 	sProgress, sFailures := sConv.Convert(task)
 
 	for {
@@ -95,6 +492,8 @@ func (bc *BatchConverter) convertOne(task Task, progress chan BatchProgressMessa
 				progress <- BatchProgressMessage{
 					Progress: progressMessage,
 					Task:     task,
+					Attempt:  attempt,
+					Batch:    bc.progressTracker.update(task.ID, progressMessage.Progress()),
 				}
 			}
 
@@ -103,10 +502,10 @@ func (bc *BatchConverter) convertOne(task Task, progress chan BatchProgressMessa
 
 			if !failed {
 				<-sConv.Closed()
-				return nil
+				return sConv.Result(), nil
 			}
 
-			return errorMessage
+			return TaskResult{}, errorMessage
 		}
 	}
 }
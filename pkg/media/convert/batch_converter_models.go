@@ -1,10 +1,19 @@
 package convert
 
 import (
+	"context"
+
+	"github.com/pkg/errors"
 	ffmpegModels "github.com/wailorman/fftb/pkg/goffmpeg/models"
 	"github.com/wailorman/fftb/pkg/media/ff"
 )
 
+// BatchTaskStartedMessage is sent when task conversion attempt is started
+type BatchTaskStartedMessage struct {
+	Task    Task
+	Attempt int
+}
+
 // MetadataReceivedBatchMessage _
 type MetadataReceivedBatchMessage struct {
 	Metadata ffmpegModels.Metadata
@@ -21,6 +30,17 @@ type InputVideoCodecDetectedBatchMessage struct {
 type BatchProgressMessage struct {
 	Progress ff.Progressable
 	Task     Task
+	// Attempt is a number of conversion attempt starting from 1
+	Attempt int
+	// Batch is a progress of all tasks in batch
+	Batch BatchProgress
+}
+
+// BatchResultMessage is sent once task is successfully converted
+type BatchResultMessage struct {
+	Result  TaskResult
+	Task    Task
+	Attempt int
 }
 
 // BatchVideoFilteringMessage _
@@ -31,6 +51,21 @@ type BatchVideoFilteringMessage struct {
 
 // BatchErrorMessage _
 type BatchErrorMessage struct {
-	Err  error
-	Task Task
+	Err     error
+	Task    Task
+	Attempt int
+	// WillRetry is true if failed attempt will be retried according to retry policy
+	WillRetry bool
+}
+
+// IsSkipped returns true if task was skipped and not failed
+func (m BatchErrorMessage) IsSkipped() bool {
+	return errors.Cause(m.Err) == ErrTaskSkipped
+}
+
+// IsInterrupted returns true if task was cancelled before it's finished
+func (m BatchErrorMessage) IsInterrupted() bool {
+	cause := errors.Cause(m.Err)
+
+	return cause == ErrInterrupted || cause == context.Canceled
 }
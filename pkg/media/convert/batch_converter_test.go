@@ -2,13 +2,18 @@ package convert_test
 
 import (
 	"context"
+	"io/ioutil"
+	"os"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/wailorman/fftb/pkg/files"
+	ffmpegModels "github.com/wailorman/fftb/pkg/goffmpeg/models"
 	"github.com/wailorman/fftb/pkg/media/convert"
+	"github.com/wailorman/fftb/pkg/media/ff"
 	"github.com/wailorman/fftb/pkg/media/minfo"
 	"golang.org/x/sync/errgroup"
 )
@@ -46,7 +51,7 @@ func Test__batchConvert(t *testing.T) {
 			infoGetter := minfo.New()
 
 			converter := convert.NewBatchConverter(ctx, infoGetter)
-			cProgress, cFailures := converter.Convert(testItem.task)
+			cProgress, cResults, cFailures := converter.Convert(testItem.task)
 
 			cg := new(errgroup.Group)
 			cg.Go(func() error {
@@ -57,6 +62,11 @@ func Test__batchConvert(t *testing.T) {
 							t.Log("Converting progress:", p.Progress.Progress())
 						}
 
+					case r, ok := <-cResults:
+						if ok {
+							t.Log("Converted:", r.Result.OutFile)
+						}
+
 					case failure, failed := <-cFailures:
 						if !failed {
 							return nil
@@ -83,3 +93,225 @@ func Test__batchConvert(t *testing.T) {
 		}()
 	}
 }
+
+type failingInfoGetter struct {
+	mutex sync.Mutex
+	calls int
+	err   error
+}
+
+func (g *failingInfoGetter) GetMediaInfo(file files.Filer) (ffmpegModels.Metadata, error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.calls++
+
+	if g.err != nil {
+		return ffmpegModels.Metadata{}, g.err
+	}
+
+	return ffmpegModels.Metadata{}, errors.New("ffprobe failed")
+}
+
+func (g *failingInfoGetter) GetFramesSummary(file files.Filer) (minfo.FramesSummary, error) {
+	return minfo.FramesSummary{}, nil
+}
+
+func (g *failingInfoGetter) GetFramesList(file files.Filer) (chan bool, chan ffmpegModels.Framer, chan error) {
+	return nil, nil, nil
+}
+
+func Test__batchConvert__retry(t *testing.T) {
+	testTable := []struct {
+		name             string
+		err              error
+		expectedMessages int
+		expectedCalls    int
+		retriedHWA       []string
+	}{
+		{
+			name:             "transient error",
+			err:              errors.Wrap(ff.ErrProcessTimeout, "ffprobe"),
+			expectedMessages: 3,
+			// batch duration is calculated before conversion, then file is probed on each attempt
+			expectedCalls: 4,
+			// hardware acceleration is disabled after first failed attempt
+			retriedHWA: []string{"nvenc", ""},
+		},
+		{
+			name:             "permanent error",
+			err:              errors.New("ffprobe failed"),
+			expectedMessages: 1,
+			expectedCalls:    2,
+		},
+	}
+
+	for _, testItem := range testTable {
+		t.Run(testItem.name, func(t *testing.T) {
+			infoGetter := &failingInfoGetter{err: testItem.err}
+			converter := convert.NewBatchConverter(context.Background(), infoGetter)
+
+			progress, results, failures := converter.Convert(convert.BatchTask{
+				Parallelism: 1,
+				Retry: convert.RetryPolicy{
+					MaxAttempts:     3,
+					Backoff:         time.Millisecond,
+					HWAccelFallback: true,
+				},
+				Tasks: []convert.Task{
+					{
+						InFile:  "/tmp/fftb_missing_input.mp4",
+						OutFile: "/tmp/fftb_missing_output.mp4",
+						Params:  convert.Params{VideoCodec: "h264", HWAccel: "nvenc"},
+					},
+				},
+			})
+
+			messages := make([]convert.BatchErrorMessage, 0)
+
+			for failures != nil {
+				select {
+				case <-progress:
+				case <-results:
+				case msg, ok := <-failures:
+					if !ok {
+						failures = nil
+						continue
+					}
+
+					messages = append(messages, msg)
+				}
+			}
+
+			if assert.Len(t, messages, testItem.expectedMessages) {
+				for i, msg := range messages {
+					assert.Equal(t, i+1, msg.Attempt)
+					assert.Equal(t, i < len(messages)-1, msg.WillRetry)
+
+					if msg.WillRetry {
+						assert.Equal(t, testItem.retriedHWA[i], msg.Task.Params.HWAccel)
+					}
+				}
+			}
+
+			assert.Equal(t, testItem.expectedCalls, infoGetter.calls)
+		})
+	}
+}
+
+func Test__batchConvert__stopOnError(t *testing.T) {
+	converter := convert.NewBatchConverter(context.Background(), &failingInfoGetter{})
+	queue := make(chan convert.Task)
+
+	progress, results, failures := converter.ConvertQueue(convert.BatchTask{
+		Parallelism:           1,
+		StopConversionOnError: true,
+	}, queue)
+
+	// queue is never closed, but batch should be finished after first error
+	go func() {
+		for _, name := range []string{"a", "b", "c"} {
+			queue <- convert.Task{
+				InFile:  "/tmp/fftb_missing_" + name + ".mp4",
+				OutFile: "/tmp/fftb_missing_" + name + "_out.mp4",
+				Params:  convert.Params{VideoCodec: "h264"},
+			}
+		}
+	}()
+
+	failuresCount := 0
+	timeout := time.After(5 * time.Second)
+
+	for failures != nil {
+		select {
+		case <-progress:
+		case <-results:
+		case _, ok := <-failures:
+			if !ok {
+				failures = nil
+				continue
+			}
+
+			failuresCount++
+		case <-timeout:
+			t.Fatal("Batch is not finished after error")
+		}
+	}
+
+	assert.Equal(t, 1, failuresCount)
+}
+
+func Test__Converter__unsupportedLargerOutputPolicy(t *testing.T) {
+	infoGetter := &failingInfoGetter{}
+	converter := convert.NewConverter(context.Background(), infoGetter)
+
+	progress, failures := converter.Convert(convert.Task{
+		InFile:  "/tmp/fftb_missing_input.mp4",
+		OutFile: "/tmp/fftb_missing_output.mp4",
+		Params: convert.Params{
+			VideoCodec:         "h264",
+			KeepIfSmaller:      true,
+			LargerOutputPolicy: "unknown",
+		},
+	})
+
+	go func() {
+		for range progress {
+		}
+	}()
+
+	err := <-failures
+
+	assert.Equal(t, convert.ErrUnsupportedLargerOutputPolicy, errors.Cause(err))
+	// policy is checked before probing and encoding input file
+	assert.Equal(t, 0, infoGetter.calls)
+}
+
+func Test__batchConvert__tasksCount(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "fftb_batch_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	outPath := files.NewPath(tmpDir)
+	journalFile := outPath.BuildFile(convert.DefaultJournalFileName)
+
+	doneTask := convert.Task{
+		ID:      "0",
+		InFile:  outPath.BuildFile("in/a.mp4").FullPath(),
+		OutFile: outPath.BuildFile("a.mp4").FullPath(),
+	}
+
+	assert.Nil(t, ioutil.WriteFile(doneTask.OutFile, []byte("converted video"), 0644))
+
+	journal := convert.NewJournal(journalFile)
+	assert.Nil(t, journal.MarkDone(doneTask, convert.TaskResult{OutFile: doneTask.OutFile}))
+
+	converter := convert.NewBatchConverter(context.Background(), &failingInfoGetter{})
+
+	progress, results, failures := converter.Convert(convert.BatchTask{
+		Parallelism: 1,
+		Resume:      true,
+		JournalPath: journalFile.FullPath(),
+		Tasks: []convert.Task{
+			doneTask,
+			{
+				InFile:  outPath.BuildFile("in/b.mp4").FullPath(),
+				OutFile: outPath.BuildFile("b.mp4").FullPath(),
+			},
+		},
+	})
+
+	// task which was done in previous run is not queued
+	assert.Equal(t, 1, converter.TasksCount())
+
+	for failures != nil {
+		select {
+		case <-progress:
+		case <-results:
+		case _, ok := <-failures:
+			if !ok {
+				failures = nil
+			}
+		}
+	}
+}
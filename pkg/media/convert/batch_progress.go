@@ -0,0 +1,111 @@
+package convert
+
+import (
+	"sync"
+	"time"
+)
+
+// BatchProgress describes progress of whole batch. Progress is measured in seconds of input media
+type BatchProgress struct {
+	ProcessedSeconds float64
+	TotalSeconds     float64
+	// Percent is in range 0-100
+	Percent float64
+	// Speed is a ratio of processed media duration to elapsed time (realtime multiplier)
+	Speed float64
+	// ETA is estimated time until batch is finished. 0 if it can't be estimated yet
+	ETA time.Duration
+}
+
+// batchProgressTracker aggregates tasks progress of parallel workers
+type batchProgressTracker struct {
+	mutex     sync.Mutex
+	startedAt time.Time
+	now       func() time.Time
+	durations map[string]float64
+	processed map[string]float64
+	total     float64
+}
+
+func newBatchProgressTracker(durations map[string]float64, now func() time.Time) *batchProgressTracker {
+	total := 0.0
+
+	for _, duration := range durations {
+		total += duration
+	}
+
+	return &batchProgressTracker{
+		startedAt: now(),
+		now:       now,
+		durations: durations,
+		processed: make(map[string]float64),
+		total:     total,
+	}
+}
+
+// add adds durations of new tasks to batch total
+func (t *batchProgressTracker) add(durations map[string]float64) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for taskID, duration := range durations {
+		t.total += duration - t.durations[taskID]
+		t.durations[taskID] = duration
+	}
+}
+
+// update saves task progress (0-100) & returns batch progress
+func (t *batchProgressTracker) update(taskID string, percent float64) BatchProgress {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if percent > 100 {
+		percent = 100
+	}
+
+	t.processed[taskID] = t.durations[taskID] * percent / 100
+
+	return t.snapshot()
+}
+
+// finish marks task as fully processed
+func (t *batchProgressTracker) finish(taskID string) BatchProgress {
+	return t.update(taskID, 100)
+}
+
+// drop removes skipped or failed task from batch, so its duration is not counted as processed
+func (t *batchProgressTracker) drop(taskID string) BatchProgress {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.total -= t.durations[taskID]
+	delete(t.durations, taskID)
+	delete(t.processed, taskID)
+
+	return t.snapshot()
+}
+
+func (t *batchProgressTracker) snapshot() BatchProgress {
+	progress := BatchProgress{TotalSeconds: t.total}
+
+	for _, processed := range t.processed {
+		progress.ProcessedSeconds += processed
+	}
+
+	if t.total > 0 {
+		progress.Percent = progress.ProcessedSeconds * 100 / t.total
+	}
+
+	elapsed := t.now().Sub(t.startedAt).Seconds()
+
+	if elapsed > 0 {
+		progress.Speed = progress.ProcessedSeconds / elapsed
+	}
+
+	if progress.Speed > 0 {
+		remaining := (t.total - progress.ProcessedSeconds) / progress.Speed
+		progress.ETA = time.Duration(remaining * float64(time.Second))
+	}
+
+	return progress
+}
@@ -0,0 +1,63 @@
+package convert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test__batchProgressTracker(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	tracker := newBatchProgressTracker(map[string]float64{"0": 100, "1": 300}, clock)
+
+	now = now.Add(10 * time.Second)
+	tracker.update("0", 50)
+	progress := tracker.update("1", 10)
+
+	assert.Equal(t, 80.0, progress.ProcessedSeconds)
+	assert.Equal(t, 400.0, progress.TotalSeconds)
+	assert.Equal(t, 20.0, progress.Percent)
+	assert.Equal(t, 8.0, progress.Speed)
+	assert.Equal(t, 40*time.Second, progress.ETA)
+
+	now = now.Add(10 * time.Second)
+	tracker.finish("0")
+	progress = tracker.finish("1")
+
+	assert.Equal(t, 100.0, progress.Percent)
+	assert.Equal(t, time.Duration(0), progress.ETA)
+}
+
+func Test__batchProgressTracker__skipped(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	tracker := newBatchProgressTracker(map[string]float64{"0": 100, "1": 300}, clock)
+
+	now = now.Add(10 * time.Second)
+	tracker.update("1", 10)
+	progress := tracker.drop("1")
+
+	// skipped task is counted neither as processed nor as remaining
+	assert.Equal(t, 0.0, progress.ProcessedSeconds)
+	assert.Equal(t, 100.0, progress.TotalSeconds)
+	assert.Equal(t, 0.0, progress.Percent)
+
+	progress = tracker.update("0", 50)
+
+	assert.Equal(t, 50.0, progress.ProcessedSeconds)
+	assert.Equal(t, 50.0, progress.Percent)
+	assert.Equal(t, 10*time.Second, progress.ETA)
+}
+
+func Test__batchProgressTracker__unknownDuration(t *testing.T) {
+	tracker := newBatchProgressTracker(map[string]float64{}, time.Now)
+
+	progress := tracker.update("0", 50)
+
+	assert.Equal(t, 0.0, progress.Percent)
+	assert.Equal(t, time.Duration(0), progress.ETA)
+}
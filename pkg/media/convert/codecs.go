@@ -19,6 +19,11 @@ func chooseCodec(task Task, metadata ffmpegModels.Metadata) (codecConfigurator,
 			task:     task,
 			metadata: metadata,
 		}, nil
+	case Av1CodecType:
+		return &Av1Codec{
+			task:     task,
+			metadata: metadata,
+		}, nil
 	default:
 		return nil, ErrCodecIsNotSupportedByEncoder
 	}
@@ -2,12 +2,18 @@ package convert
 
 import (
 	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/wailorman/fftb/pkg/chwg"
 	"github.com/wailorman/fftb/pkg/files"
+	ffmpegModels "github.com/wailorman/fftb/pkg/goffmpeg/models"
 	"github.com/wailorman/fftb/pkg/media/ff"
 	"github.com/wailorman/fftb/pkg/media/minfo"
+	"github.com/wailorman/fftb/pkg/media/quality"
 	mediaUtils "github.com/wailorman/fftb/pkg/media/utils"
 )
 
@@ -17,6 +23,9 @@ type Converter struct {
 	ctx        context.Context
 	infoGetter minfo.Getter
 	ffworker   *ff.Instance
+	result     TaskResult
+
+	stallTimeout time.Duration
 }
 
 // NewConverter _
@@ -31,6 +40,13 @@ func NewConverter(ctx context.Context, infoGetter minfo.Getter) *Converter {
 	}
 }
 
+// SetStallTimeout sets maximum time ffmpeg allowed to not report progress (ff.ProcessTimeout by default).
+// Should be called before Convert()
+func (c *Converter) SetStallTimeout(timeout time.Duration) {
+	c.stallTimeout = timeout
+	c.ffworker.SetProcessTimeout(timeout)
+}
+
 // Convert _
 func (c *Converter) Convert(task Task) (
 	progress chan ff.Progressable,
@@ -48,50 +64,118 @@ func (c *Converter) Convert(task Task) (
 		defer close(failures)
 		defer c.wg.Done()
 
+		if err = newSizeCheck(task).validate(); err != nil {
+			failures <- errors.Wrap(err, "Checking params")
+			return
+		}
+
 		inFile := files.NewFile(task.InFile)
-		outFile := files.NewFile(task.OutFile)
+		var outFile files.Filer
+
+		if task.Params.ReplaceOriginal {
+			// converted file is placed beside original one, so it can be renamed atomically.
+			// Temporary file left after crash is overwritten
+			outFile = replaceTempFile(inFile)
+			defer outFile.Remove()
+		} else {
+			task, err = resolveOutputConflict(task, isOutputTaken)
+
+			if err != nil {
+				failures <- errors.Wrap(err, "Resolving output conflict")
+				return
+			}
 
-		err = c.ffworker.Init(inFile, outFile)
+			outFile = files.NewFile(task.OutFile)
+		}
+
+		metadata, err := c.infoGetter.GetMediaInfo(inFile)
 
 		if err != nil {
-			failures <- errors.Wrap(err, "ffworker initializing error")
+			failures <- errors.Wrap(err, "Getting file metadata")
 			return
 		}
 
-		mediaFile := c.ffworker.MediaFile()
+		c.ffworker.SetInputMetadata(metadata)
 
-		metadata, err := c.infoGetter.GetMediaInfo(inFile)
+		err = c.ffworker.Init(inFile, outFile)
 
 		if err != nil {
-			failures <- errors.Wrap(err, "Getting file metadata")
+			failures <- errors.Wrap(err, "ffworker initializing error")
 			return
 		}
 
+		mediaFile := c.ffworker.MediaFile()
+
 		// TODO: log metadata
 
 		if !mediaUtils.IsVideo(metadata) {
-			failures <- errors.Wrap(err, "Input file is not video")
+			failures <- ErrFileIsNotVideo
 			return
 		}
 
-		codec, err := chooseCodec(task, metadata)
+		policy, reason, err := alreadyEncodedAction(task, metadata)
 
 		if err != nil {
-			failures <- errors.Wrap(err, "Choosing codec")
+			failures <- errors.Wrap(err, "Checking input video codec")
 			return
 		}
 
-		err = codec.configure(mediaFile)
+		if policy == SkipAlreadyEncodedPolicy {
+			failures <- errors.Wrap(ErrTaskSkipped, reason)
+			return
+		}
+
+		remux := policy == RemuxAlreadyEncodedPolicy
+
+		var measurer quality.Measurer
+
+		if len(task.Params.VerifyQuality) > 0 {
+			measurer, err = quality.New(c.ctx, task.Params.VerifyQuality)
+
+			if err != nil {
+				failures <- errors.Wrap(err, "Building quality measurer")
+				return
+			}
+		}
+
+		if NeedsQualitySearch(task) && !remux {
+			task.Params.VideoQuality, err = NewQualitySearch(c.ctx).Search(task)
+
+			if err != nil {
+				failures <- errors.Wrap(err, "Searching video quality")
+				return
+			}
+
+			c.result.VideoQuality = task.Params.VideoQuality
+		}
+
+		if remux {
+			err = configureRemux(task, metadata, mediaFile)
+		} else {
+			task, err = applyTargetSize(task, metadata)
+
+			if err != nil {
+				failures <- errors.Wrap(err, "Calculating bitrate for target size")
+				return
+			}
+
+			err = configureMediaFile(task, metadata, mediaFile)
+		}
 
 		if err != nil {
-			failures <- errors.Wrap(err, "Configuring codec")
+			failures <- err
 			return
 		}
 
-		err = newVideoScale(task, metadata).configure(mediaFile)
+		modTime, err := getModTime(inFile)
 
 		if err != nil {
-			failures <- errors.Wrap(err, "Configuring video scale")
+			failures <- errors.Wrap(err, "Getting input file modification time")
+			return
+		}
+
+		if err = newMetadataCopy(task, modTime).configure(mediaFile); err != nil {
+			failures <- errors.Wrap(err, "Configuring metadata")
 			return
 		}
 
@@ -102,27 +186,81 @@ func (c *Converter) Convert(task Task) (
 			return
 		}
 
-		fProgress, fFailures := c.ffworker.Start()
+		passes := 1
+
+		if isTwoPass(task, mediaFile) {
+			passes = 2
 
-		for {
-			select {
-			case <-c.ctx.Done():
-				failures <- c.ctx.Err()
+			passLogDir, err := ioutil.TempDir("", "fftb_passlog")
+
+			if err != nil {
+				failures <- errors.Wrap(err, "Creating pass log directory")
 				return
+			}
 
-			case failure, failed := <-fFailures:
-				if !failed {
-					<-c.ffworker.Closed()
-					return
-				}
+			defer os.RemoveAll(passLogDir)
 
-				failures <- failure
+			passLogFile := filepath.Join(passLogDir, "passlog")
+
+			err = c.runFirstPass(task, metadata, inFile, passLogFile, progress)
+
+			if err != nil {
+				failures <- errors.Wrap(c.interruptionError(err), "Running first pass")
 				return
+			}
+
+			setEncodingPass(mediaFile, 2, passLogFile)
+		}
+
+		err = c.runPass(c.ffworker, progress, passes, passes)
 
-			case progressMessage, ok := <-fProgress:
-				if ok {
-					progress <- progressMessage
-				}
+		if err != nil {
+			if c.ctx.Err() != nil {
+				// output file was not finished, so it's useless
+				outFile.Remove()
+			}
+
+			failures <- c.interruptionError(err)
+			return
+		}
+
+		c.result.OutFile = outFile.FullPath()
+
+		err = newSizeCheck(task).apply(inFile, outFile, &c.result)
+
+		if err != nil {
+			failures <- err
+			return
+		}
+
+		if measurer != nil && !c.result.KeptInput {
+			scores, err := measurer.Measure(inFile, outFile)
+
+			if err != nil {
+				failures <- errors.Wrap(err, "Verifying quality")
+				return
+			}
+
+			c.result.Quality = &scores
+		}
+
+		if task.Params.ReplaceOriginal {
+			c.result.OutFile = inFile.FullPath()
+
+			if c.result.KeptInput {
+				return
+			}
+
+			err = c.replaceOriginal(task, metadata, inFile, outFile, modTime)
+
+			if err != nil {
+				failures <- errors.Wrap(err, "Replacing original file")
+				return
+			}
+		} else if !task.Params.SkipMetadataCopy {
+			if err = outFile.SetChTime(modTime); err != nil {
+				failures <- errors.Wrap(err, "Setting output file modification time")
+				return
 			}
 		}
 	}()
@@ -130,6 +268,145 @@ func (c *Converter) Convert(task Task) (
 	return progress, failures
 }
 
+// interruptionError replaces ffmpeg error with ErrInterrupted or ErrTaskTimeout if conversion was cancelled.
+// ffmpeg can receive interruption signal from terminal and exit with its own error
+func (c *Converter) interruptionError(err error) error {
+	switch c.ctx.Err() {
+	case nil:
+		return err
+	case context.DeadlineExceeded:
+		return errors.Wrap(ErrTaskTimeout, err.Error())
+	default:
+		return errors.Wrap(ErrInterrupted, err.Error())
+	}
+}
+
+// runFirstPass runs video analysis without writing output file
+func (c *Converter) runFirstPass(
+	task Task,
+	metadata ffmpegModels.Metadata,
+	inFile files.Filer,
+	passLogFile string,
+	progress chan ff.Progressable,
+) error {
+	ffworker := ff.New(c.ctx)
+	ffworker.SetInputMetadata(metadata)
+
+	if c.stallTimeout > 0 {
+		ffworker.SetProcessTimeout(c.stallTimeout)
+	}
+
+	err := ffworker.Init(inFile, files.NewFile(os.DevNull))
+
+	if err != nil {
+		return errors.Wrap(err, "ffworker initializing error")
+	}
+
+	mediaFile := ffworker.MediaFile()
+
+	if err = configureVideo(task, metadata, mediaFile); err != nil {
+		return err
+	}
+
+	configureFirstPass(mediaFile, passLogFile)
+
+	return c.runPass(ffworker, progress, 1, 2)
+}
+
+// runPass starts ffworker and redirects its progress messages until it's done
+func (c *Converter) runPass(ffworker *ff.Instance, progress chan ff.Progressable, pass, passes int) error {
+	fProgress, fFailures := ffworker.Start()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			// ffmpeg is still stopping, output file can be removed only after its exit
+			drainPass(fProgress, fFailures)
+			<-ffworker.Closed()
+
+			return c.ctx.Err()
+
+		case failure, failed := <-fFailures:
+			if !failed {
+				<-ffworker.Closed()
+				return nil
+			}
+
+			return failure
+
+		case progressMessage, ok := <-fProgress:
+			if !ok {
+				continue
+			}
+
+			if passes > 1 {
+				progressMessage = &passProgress{Progressable: progressMessage, pass: pass, passes: passes}
+			}
+
+			progress <- progressMessage
+		}
+	}
+}
+
+// drainPass reads ffworker channels until they are closed, so ffworker goroutine is not blocked on send
+func drainPass(progress chan ff.Progressable, failures chan error) {
+	for progress != nil || failures != nil {
+		select {
+		case _, ok := <-progress:
+			if !ok {
+				progress = nil
+			}
+		case _, ok := <-failures:
+			if !ok {
+				failures = nil
+			}
+		}
+	}
+}
+
+func configureMediaFile(task Task, metadata ffmpegModels.Metadata, mediaFile *ffmpegModels.Mediafile) error {
+	if err := configureVideo(task, metadata, mediaFile); err != nil {
+		return err
+	}
+
+	if err := newAudioTranscoding(task).configure(mediaFile); err != nil {
+		return errors.Wrap(err, "Configuring audio")
+	}
+
+	if err := newStreamMapping(task, metadata).configure(mediaFile); err != nil {
+		return errors.Wrap(err, "Configuring streams mapping")
+	}
+
+	return nil
+}
+
+func configureVideo(task Task, metadata ffmpegModels.Metadata, mediaFile *ffmpegModels.Mediafile) error {
+	codec, err := chooseCodec(task, metadata)
+
+	if err != nil {
+		return errors.Wrap(err, "Choosing codec")
+	}
+
+	if err = codec.configure(mediaFile); err != nil {
+		return errors.Wrap(err, "Configuring codec")
+	}
+
+	if err = newVideoScale(task, metadata).configure(mediaFile); err != nil {
+		return errors.Wrap(err, "Configuring video scale")
+	}
+
+	if err = newFrameRateConversion(task, metadata).configure(mediaFile); err != nil {
+		return errors.Wrap(err, "Configuring frame rate")
+	}
+
+	return nil
+}
+
+// Result returns conversion result. Should be called after conversion is finished
+func (c *Converter) Result() TaskResult {
+	return c.result
+}
+
 // Closed _
 func (c *Converter) Closed() <-chan struct{} {
 	return c.wg.Closed()
@@ -1,8 +1,11 @@
 package convert
 
 import (
+	"time"
+
 	"github.com/pkg/errors"
 	"github.com/wailorman/fftb/pkg/files"
+	"github.com/wailorman/fftb/pkg/media/quality"
 )
 
 const (
@@ -10,6 +13,10 @@ const (
 	NvencHWAccelType = "nvenc"
 	// VTBHWAccelType _
 	VTBHWAccelType = "videotoolbox"
+	// VaapiHWAccelType _
+	VaapiHWAccelType = "vaapi"
+	// QsvHWAccelType _
+	QsvHWAccelType = "qsv"
 )
 
 const (
@@ -17,6 +24,8 @@ const (
 	HevcCodecType = "hevc"
 	// H264CodecType _
 	H264CodecType = "h264"
+	// Av1CodecType _
+	Av1CodecType = "av1"
 )
 
 // VideoFileFilteringMessage _
@@ -30,7 +39,27 @@ type VideoFileFilteringMessage struct {
 type BatchTask struct {
 	Parallelism           int    `yaml:"parallelism"`
 	StopConversionOnError bool   `yaml:"stop_conversion_on_error"`
+	JournalPath           string `yaml:"journal_path,omitempty"`
+	Resume                bool   `yaml:"resume,omitempty"`
 	Tasks                 []Task `yaml:"tasks"`
+
+	// TaskTimeout limits conversion time of each task attempt
+	TaskTimeout time.Duration `yaml:"task_timeout,omitempty"`
+	// StallTimeout limits time ffmpeg allowed to not report progress (ff.ProcessTimeout by default)
+	StallTimeout time.Duration `yaml:"stall_timeout,omitempty"`
+	Retry        RetryPolicy   `yaml:"retry,omitempty"`
+
+	PlannedCollisions []OutputCollision `yaml:"planned_collisions,omitempty"`
+}
+
+// RetryPolicy describes how failed tasks are converted again
+type RetryPolicy struct {
+	// MaxAttempts is a number of conversion attempts including first one
+	MaxAttempts int `yaml:"max_attempts,omitempty"`
+	// Backoff is a delay before second attempt. Delay is doubled before each next attempt
+	Backoff time.Duration `yaml:"backoff,omitempty"`
+	// HWAccelFallback disables hardware acceleration for next attempts
+	HWAccelFallback bool `yaml:"hw_accel_fallback,omitempty"`
 }
 
 // Task _
@@ -43,13 +72,68 @@ type Task struct {
 
 // Params _
 type Params struct {
-	VideoCodec       string `yaml:"video_codec"`
-	HWAccel          string `yaml:"hw_accel"`
-	VideoBitRate     string `yaml:"video_bit_rate"`
-	VideoQuality     int    `yaml:"video_quality"`
-	Preset           string `yaml:"preset"`
-	Scale            string `yaml:"scale"`
-	KeyframeInterval int    `yaml:"keyframe_interval"`
+	VideoCodec       string  `yaml:"video_codec"`
+	VideoEncoder     string  `yaml:"video_encoder,omitempty"`
+	HWAccel          string  `yaml:"hw_accel"`
+	HWDevice         string  `yaml:"hw_device,omitempty"`
+	VideoBitRate     string  `yaml:"video_bit_rate"`
+	VideoQuality     int     `yaml:"video_quality"`
+	TargetSize       string  `yaml:"target_size,omitempty"`
+	TargetVMAF       float64 `yaml:"target_vmaf,omitempty"`
+	TwoPass          bool    `yaml:"two_pass,omitempty"`
+	Preset           string  `yaml:"preset"`
+	Scale            string  `yaml:"scale"`
+	MaxWidth         int     `yaml:"max_width,omitempty"`
+	MaxHeight        int     `yaml:"max_height,omitempty"`
+	KeyframeInterval int     `yaml:"keyframe_interval"`
+	FrameRate        string  `yaml:"frame_rate,omitempty"`
+	FrameRateMode    string  `yaml:"frame_rate_mode,omitempty"`
+
+	AudioCodec      string `yaml:"audio_codec,omitempty"`
+	AudioBitRate    string `yaml:"audio_bit_rate,omitempty"`
+	AudioChannels   int    `yaml:"audio_channels,omitempty"`
+	AudioSampleRate int    `yaml:"audio_sample_rate,omitempty"`
+	DropAudio       bool   `yaml:"drop_audio,omitempty"`
+
+	KeepAllStreams bool     `yaml:"keep_all_streams,omitempty"`
+	AudioTracks    []string `yaml:"audio_tracks,omitempty"`
+	MergeAudio     bool     `yaml:"merge_audio,omitempty"`
+	CopySubtitles  bool     `yaml:"copy_subtitles,omitempty"`
+	CopyData       bool     `yaml:"copy_data,omitempty"`
+
+	OutputConflictPolicy string `yaml:"output_conflict_policy"`
+
+	AlreadyEncodedPolicy string `yaml:"already_encoded_policy,omitempty"`
+	CompareBitRate       bool   `yaml:"compare_bit_rate,omitempty"`
+
+	KeepIfSmaller      bool    `yaml:"keep_if_smaller,omitempty"`
+	MaxSizeRatio       float64 `yaml:"max_size_ratio,omitempty"`
+	LargerOutputPolicy string  `yaml:"larger_output_policy,omitempty"`
+
+	SkipMetadataCopy bool `yaml:"skip_metadata_copy,omitempty"`
+
+	ReplaceOriginal bool   `yaml:"replace_original,omitempty"`
+	TrashPath       string `yaml:"trash_path,omitempty"`
+
+	VerifyQuality []string `yaml:"verify_quality,omitempty"`
+}
+
+// TaskResult _
+type TaskResult struct {
+	OutFile string
+	Quality *quality.Scores
+	// VideoQuality is a value chosen by quality search
+	VideoQuality int
+
+	InputSize  int
+	OutputSize int
+	// KeptInput is true when output was replaced with input file because it wasn't smaller
+	KeptInput bool
+}
+
+// SavedBytes returns difference between input & output file sizes
+func (r TaskResult) SavedBytes() int {
+	return r.InputSize - r.OutputSize
 }
 
 // ErrFileIsNotVideo _
@@ -67,11 +151,59 @@ var ErrUnsupportedHWAccelType = errors.New("Unsupported hardware acceleration ty
 // ErrUnsupportedScale _
 var ErrUnsupportedScale = errors.New("Unsupported scale")
 
+// ErrUnsupportedPreset _
+var ErrUnsupportedPreset = errors.New("Unsupported preset")
+
+// ErrUnsupportedAudioCodec _
+var ErrUnsupportedAudioCodec = errors.New("Unsupported audio codec")
+
+// ErrAudioParamsRequireEncoding _
+var ErrAudioParamsRequireEncoding = errors.New("Audio bitrate, channels & sample rate can't be changed without audio codec")
+
+// ErrAudioTrackNotFound _
+var ErrAudioTrackNotFound = errors.New("Audio track not found")
+
+// ErrUnsupportedFrameRate _
+var ErrUnsupportedFrameRate = errors.New("Unsupported frame rate")
+
+// ErrUnsupportedFrameRateMode _
+var ErrUnsupportedFrameRateMode = errors.New("Unsupported frame rate mode")
+
+// ErrInvalidSize _
+var ErrInvalidSize = errors.New("Invalid size value")
+
+// ErrUnknownDuration _
+var ErrUnknownDuration = errors.New("Unknown input file duration")
+
+// ErrTargetSizeTooSmall _
+var ErrTargetSizeTooSmall = errors.New("Target size is too small for input file duration")
+
 // ErrResolutionNotSupportScaling _
 var ErrResolutionNotSupportScaling = errors.New("Resolution not support scaling")
 
 // ErrOutputFileExistsOrIsDirectory _
 var ErrOutputFileExistsOrIsDirectory = errors.New("Output file exists or is directory")
 
+// ErrUnsupportedOutputConflictPolicy _
+var ErrUnsupportedOutputConflictPolicy = errors.New("Unsupported output conflict policy")
+
+// ErrUnsupportedAlreadyEncodedPolicy _
+var ErrUnsupportedAlreadyEncodedPolicy = errors.New("Unsupported already encoded policy")
+
+// ErrUnsupportedLargerOutputPolicy _
+var ErrUnsupportedLargerOutputPolicy = errors.New("Unsupported larger output policy")
+
+// ErrInvalidReplacement _
+var ErrInvalidReplacement = errors.New("Converted file can't replace original")
+
+// ErrInterrupted happened when conversion was cancelled (i.e. by SIGINT)
+var ErrInterrupted = errors.New("Conversion interrupted")
+
+// ErrTaskTimeout happened when task was not finished in BatchTask.TaskTimeout
+var ErrTaskTimeout = errors.New("Task timeout")
+
+// ErrTaskSkipped happened when task was not converted intentionally
+var ErrTaskSkipped = errors.New("Task skipped")
+
 // ErrVtbQualityNotSupported _
 var ErrVtbQualityNotSupported = errors.New("Video quality option is not supported by Apple VideoToolBox")
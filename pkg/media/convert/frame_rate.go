@@ -0,0 +1,90 @@
+package convert
+
+import (
+	"math"
+	"strings"
+
+	ffmpegModels "github.com/wailorman/fftb/pkg/goffmpeg/models"
+	mediaUtils "github.com/wailorman/fftb/pkg/media/utils"
+)
+
+const (
+	// SourceFrameRate keeps frame rate of input file
+	SourceFrameRate = "source"
+)
+
+const (
+	// PassthroughFrameRateMode keeps original frames timestamps (default)
+	PassthroughFrameRateMode = "passthrough"
+	// CfrFrameRateMode always converts video to constant frame rate
+	CfrFrameRateMode = "cfr"
+	// AutoFrameRateMode converts video to constant frame rate only if variable frame rate is detected
+	AutoFrameRateMode = "auto"
+)
+
+type frameRateConversion struct {
+	task     Task
+	metadata ffmpegModels.Metadata
+}
+
+func newFrameRateConversion(task Task, metadata ffmpegModels.Metadata) *frameRateConversion {
+	return &frameRateConversion{
+		task:     task,
+		metadata: metadata,
+	}
+}
+
+func (fc *frameRateConversion) configure(mediaFile *ffmpegModels.Mediafile) error {
+	frameRate, err := parseTargetFrameRate(fc.task.Params.FrameRate)
+
+	if err != nil {
+		return err
+	}
+
+	var normalize bool
+
+	switch fc.task.Params.FrameRateMode {
+	case "", PassthroughFrameRateMode:
+		// frames should be duplicated or dropped to reach target frame rate
+		normalize = frameRate != ""
+	case CfrFrameRateMode:
+		normalize = true
+	case AutoFrameRateMode:
+		normalize = frameRate != "" || mediaUtils.IsVariableFrameRate(fc.metadata)
+	default:
+		return ErrUnsupportedFrameRateMode
+	}
+
+	if !normalize {
+		return nil
+	}
+
+	mediaFile.SetVsync(false)
+	mediaFile.SetVideoSyncMethod("cfr")
+
+	if frameRate != "" {
+		mediaFile.SetFrameRate(frameRate)
+	}
+
+	return nil
+}
+
+// parseTargetFrameRate returns empty string if frame rate of input file should be kept.
+// Integer (30), decimal (29.97) & rational (30000/1001) values are accepted
+func parseTargetFrameRate(value string) (string, error) {
+	if value == "" || value == SourceFrameRate {
+		return "", nil
+	}
+
+	if strings.Count(value, "/") > 1 {
+		return "", ErrUnsupportedFrameRate
+	}
+
+	frameRate, err := mediaUtils.ParseFrameRate(value)
+
+	if err != nil || frameRate <= 0 || math.IsNaN(frameRate) || math.IsInf(frameRate, 0) {
+		return "", ErrUnsupportedFrameRate
+	}
+
+	return value, nil
+}
@@ -0,0 +1,107 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test__frameRateConversion__configure(t *testing.T) {
+	assert := assert.New(t)
+
+	cfrMetadata := newTestVideoMetadata("h264")
+	cfrMetadata.Streams[0].RFrameRrate = "30000/1001"
+	cfrMetadata.Streams[0].AvgFrameRate = "30000/1001"
+
+	vfrMetadata := newTestVideoMetadata("h264")
+	vfrMetadata.Streams[0].RFrameRrate = "60/1"
+	vfrMetadata.Streams[0].AvgFrameRate = "5521000/92383"
+
+	testTable := []struct {
+		params          Params
+		variable        bool
+		expectedCommand []string
+		expectedErr     error
+	}{
+		{
+			params:          Params{},
+			expectedCommand: []string{"-vsync", "0", "-i", "in.mp4", "out.mp4"},
+		},
+		{
+			params:          Params{FrameRate: SourceFrameRate},
+			variable:        true,
+			expectedCommand: []string{"-vsync", "0", "-i", "in.mp4", "out.mp4"},
+		},
+		{
+			params:          Params{FrameRate: "30"},
+			expectedCommand: []string{"-i", "in.mp4", "-r", "30", "-vsync", "cfr", "out.mp4"},
+		},
+		{
+			params:          Params{FrameRateMode: CfrFrameRateMode},
+			expectedCommand: []string{"-i", "in.mp4", "-vsync", "cfr", "out.mp4"},
+		},
+		{
+			params:          Params{FrameRateMode: AutoFrameRateMode},
+			expectedCommand: []string{"-vsync", "0", "-i", "in.mp4", "out.mp4"},
+		},
+		{
+			params:          Params{FrameRateMode: AutoFrameRateMode},
+			variable:        true,
+			expectedCommand: []string{"-i", "in.mp4", "-vsync", "cfr", "out.mp4"},
+		},
+		{
+			params:          Params{FrameRate: "29.97"},
+			expectedCommand: []string{"-i", "in.mp4", "-r", "29.97", "-vsync", "cfr", "out.mp4"},
+		},
+		{
+			params:          Params{FrameRate: "30000/1001"},
+			expectedCommand: []string{"-i", "in.mp4", "-r", "30000/1001", "-vsync", "cfr", "out.mp4"},
+		},
+		{
+			params:      Params{FrameRate: "0"},
+			expectedErr: ErrUnsupportedFrameRate,
+		},
+		{
+			params:      Params{FrameRate: "30/0"},
+			expectedErr: ErrUnsupportedFrameRate,
+		},
+		{
+			params:      Params{FrameRate: "30/1/2"},
+			expectedErr: ErrUnsupportedFrameRate,
+		},
+		{
+			params:      Params{FrameRate: "NaN"},
+			expectedErr: ErrUnsupportedFrameRate,
+		},
+		{
+			params:      Params{FrameRate: "fast"},
+			expectedErr: ErrUnsupportedFrameRate,
+		},
+		{
+			params:      Params{FrameRateMode: "vfr"},
+			expectedErr: ErrUnsupportedFrameRateMode,
+		},
+	}
+
+	for i, testItem := range testTable {
+		metadata := cfrMetadata
+
+		if testItem.variable {
+			metadata = vfrMetadata
+		}
+
+		mediaFile := newTestMediaFile()
+		mediaFile.SetVsync(true)
+
+		err := newFrameRateConversion(Task{Params: testItem.params}, metadata).configure(mediaFile)
+
+		if testItem.expectedErr != nil {
+			assert.Equal(testItem.expectedErr, errors.Cause(err), i)
+			continue
+		}
+
+		assert.Nil(err, i)
+		assert.Equal(testItem.expectedCommand, mediaFile.ToStrCommand(), i)
+	}
+}
@@ -26,7 +26,6 @@ func (c *HevcCodec) configure(mediaFile *ffmpegModels.Mediafile) error {
 	mediaFile.SetPreset(c.task.Params.Preset)
 	mediaFile.SetHideBanner(true)
 	mediaFile.SetVsync(true)
-	mediaFile.SetAudioCodec("copy")
 	mediaFile.SetMaxMuxingQueueSize(102400)
 	mediaFile.SetVideoTag("hvc1")
 
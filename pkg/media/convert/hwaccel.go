@@ -20,11 +20,29 @@ func chooseHwAccel(task Task, metadata ffmpegModels.Metadata) hwAccelerator {
 			task:     task,
 			metadata: metadata,
 		}
+	case VaapiHWAccelType:
+		return &vaapiHWAccel{
+			task:     task,
+			metadata: metadata,
+		}
+	case QsvHWAccelType:
+		return &qsvHWAccel{
+			task:     task,
+			metadata: metadata,
+		}
 	default:
 		return &emptyHwAccel{}
 	}
 }
 
+// resetCPUEncoderOptions removes options which are set by CPU codec configurators,
+// but not supported by hardware encoders
+func resetCPUEncoderOptions(mediaFile *ffmpegModels.Mediafile) {
+	mediaFile.SetCRF(0)
+	mediaFile.SetLibx265Params(nil)
+	mediaFile.SetCPUUsed(0)
+}
+
 type emptyHwAccel struct {
 }
 
@@ -0,0 +1,73 @@
+package convert
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wailorman/fftb/pkg/goffmpeg/transcoder"
+)
+
+func Test__hwAccelerators__command(t *testing.T) {
+	assert := assert.New(t)
+
+	testTable := []struct {
+		inputCodec      string
+		params          Params
+		expectedCommand []string
+	}{
+		{
+			inputCodec: "h264",
+			params:     Params{VideoCodec: HevcCodecType, HWAccel: VaapiHWAccelType, VideoQuality: 30, Preset: "slow", Scale: FixedHalfScaleType},
+			expectedCommand: []string{
+				"-y", "-hwaccel", "vaapi", "-hwaccel_device", "/dev/dri/renderD128", "-hwaccel_output_format", "vaapi",
+				"-vsync", "0", "-i", "in.mp4", "-hide_banner", "-c:v", "hevc_vaapi", "-c:a", "copy", "-rc_mode", "CQP",
+				"-max_muxing_queue_size", "102400", "-qp", "30", "-vf", "scale_vaapi=960:540", "-tag:v", "hvc1", "out.mp4",
+			},
+		},
+		{
+			inputCodec: "prores",
+			params:     Params{VideoCodec: H264CodecType, HWAccel: VaapiHWAccelType, HWDevice: "/dev/dri/renderD129", VideoBitRate: "10M", Scale: FixedHalfScaleType},
+			expectedCommand: []string{
+				"-y", "-init_hw_device", "vaapi=hw:/dev/dri/renderD129", "-filter_hw_device", "hw",
+				"-vsync", "0", "-i", "in.mp4", "-hide_banner", "-c:v", "h264_vaapi", "-b:v", "10M", "-c:a", "copy",
+				"-max_muxing_queue_size", "102400", "-vf", "format=nv12,hwupload,scale_vaapi=960:540", "out.mp4",
+			},
+		},
+		{
+			inputCodec: "hevc",
+			params:     Params{VideoCodec: H264CodecType, HWAccel: QsvHWAccelType, VideoQuality: 25, Preset: "slow", Scale: FixedQuarterScaleType},
+			expectedCommand: []string{
+				"-y", "-hwaccel", "qsv", "-hwaccel_output_format", "qsv", "-vsync", "0", "-c:v", "hevc_qsv",
+				"-i", "in.mp4", "-hide_banner", "-c:v", "h264_qsv", "-c:a", "copy", "-global_quality", "25",
+				"-max_muxing_queue_size", "102400", "-preset", "slow", "-vf", "scale_qsv=480:270", "out.mp4",
+			},
+		},
+		{
+			inputCodec: "prores",
+			params:     Params{VideoCodec: Av1CodecType, HWAccel: QsvHWAccelType, HWDevice: "/dev/dri/renderD128", VideoQuality: 30},
+			expectedCommand: []string{
+				"-y", "-init_hw_device", "qsv=hw:hw,child_device=/dev/dri/renderD128", "-filter_hw_device", "hw",
+				"-vsync", "0", "-i", "in.mp4", "-hide_banner", "-c:v", "av1_qsv", "-c:a", "copy", "-global_quality", "30",
+				"-max_muxing_queue_size", "102400", "-vf", "format=nv12,hwupload=extra_hw_frames=64", "out.mp4",
+			},
+		},
+	}
+
+	for i, testItem := range testTable {
+		task := Task{Params: testItem.params}
+		metadata := newTestVideoMetadata(testItem.inputCodec)
+		mediaFile := newTestMediaFile()
+
+		codec, err := chooseCodec(task, metadata)
+		assert.Nil(err, i)
+		assert.Nil(codec.configure(mediaFile), i)
+		assert.Nil(newVideoScale(task, metadata).configure(mediaFile), i)
+		assert.Nil(newAudioTranscoding(task).configure(mediaFile), i)
+
+		trans := transcoder.New(context.Background())
+		trans.SetMediaFile(mediaFile)
+
+		assert.Equal(testItem.expectedCommand, trans.GetCommand(), i)
+	}
+}
@@ -0,0 +1,293 @@
+package convert
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/wailorman/fftb/pkg/files"
+	"github.com/wailorman/fftb/pkg/media/quality"
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultJournalFileName is a journal file name placed in output path
+const DefaultJournalFileName = ".fftb_journal.yaml"
+
+// JournalTaskState _
+type JournalTaskState string
+
+const (
+	// JournalTaskPending _
+	JournalTaskPending JournalTaskState = "pending"
+	// JournalTaskRunning _
+	JournalTaskRunning JournalTaskState = "running"
+	// JournalTaskDone _
+	JournalTaskDone JournalTaskState = "done"
+	// JournalTaskFailed _
+	JournalTaskFailed JournalTaskState = "failed"
+	// JournalTaskSkipped _
+	JournalTaskSkipped JournalTaskState = "skipped"
+)
+
+// JournalRecord _
+type JournalRecord struct {
+	ID            string           `yaml:"id"`
+	InFile        string           `yaml:"in_file"`
+	OutFile       string           `yaml:"out_file"`
+	ResultFile    string           `yaml:"result_file,omitempty"`
+	State         JournalTaskState `yaml:"state"`
+	OutputSize    int              `yaml:"output_size,omitempty"`
+	OutputModTime time.Time        `yaml:"output_mod_time,omitempty"`
+	Error         string           `yaml:"error,omitempty"`
+	Quality       *quality.Scores  `yaml:"quality,omitempty"`
+	VideoQuality  int              `yaml:"video_quality,omitempty"`
+	SavedBytes    int              `yaml:"saved_bytes,omitempty"`
+	UpdatedAt     time.Time        `yaml:"updated_at"`
+}
+
+type journalContent struct {
+	Tasks map[string]*JournalRecord `yaml:"tasks"`
+}
+
+// Journal keeps batch tasks states on disk, so interrupted batch can be resumed
+type Journal struct {
+	file    files.Filer
+	mutex   sync.Mutex
+	content journalContent
+}
+
+// NewJournal _
+func NewJournal(file files.Filer) *Journal {
+	return &Journal{
+		file: file,
+		content: journalContent{
+			Tasks: make(map[string]*JournalRecord),
+		},
+	}
+}
+
+// Load reads journal from disk. Missing journal file is not an error
+func (j *Journal) Load() error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if !j.file.IsExist() {
+		return nil
+	}
+
+	content, err := j.file.ReadAllContent()
+
+	if err != nil {
+		return errors.Wrap(err, "Reading journal file")
+	}
+
+	err = yaml.Unmarshal([]byte(content), &j.content)
+
+	if err != nil {
+		return errors.Wrap(err, "Parsing journal file")
+	}
+
+	if j.content.Tasks == nil {
+		j.content.Tasks = make(map[string]*JournalRecord)
+	}
+
+	return nil
+}
+
+// Record returns task record or nil if journal has no information about task
+func (j *Journal) Record(task Task) *JournalRecord {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	record, ok := j.content.Tasks[task.ID]
+
+	if !ok || record.InFile != task.InFile || record.OutFile != task.OutFile {
+		return nil
+	}
+
+	recordCopy := *record
+
+	return &recordCopy
+}
+
+// IsDone returns true if task was finished successfully and its output file is still in place.
+// Output is checked by size & modification time: hashing of multi-GB files would block conversion
+func (j *Journal) IsDone(task Task) bool {
+	record := j.Record(task)
+
+	if record == nil || record.State != JournalTaskDone {
+		return false
+	}
+
+	outFile := files.NewFile(record.ResultFile)
+
+	if record.ResultFile == "" || !outFile.IsExist() {
+		return false
+	}
+
+	size, err := outFile.Size()
+
+	if err != nil || size != record.OutputSize {
+		return false
+	}
+
+	modTime, err := getModTime(outFile)
+
+	if err != nil {
+		return false
+	}
+
+	return modTime.Equal(record.OutputModTime)
+}
+
+// IsProcessed returns true if task was converted or skipped in previous runs
+func (j *Journal) IsProcessed(task Task) bool {
+	if j.IsDone(task) {
+		return true
+	}
+
+	record := j.Record(task)
+
+	return record != nil && record.State == JournalTaskSkipped
+}
+
+// MarkPending _
+func (j *Journal) MarkPending(task Task) error {
+	return j.update(task, func(record *JournalRecord) {
+		record.State = JournalTaskPending
+		record.ResultFile = ""
+		record.OutputSize = 0
+		record.OutputModTime = time.Time{}
+		record.Quality = nil
+		record.VideoQuality = 0
+		record.SavedBytes = 0
+		record.Error = ""
+	})
+}
+
+// MarkRunning _
+func (j *Journal) MarkRunning(task Task) error {
+	return j.update(task, func(record *JournalRecord) {
+		record.State = JournalTaskRunning
+		record.Error = ""
+	})
+}
+
+// MarkDone saves task as finished with output file size & modification time.
+// resultFile can differ from task's output file when it was renamed due to conflict
+func (j *Journal) MarkDone(task Task, result TaskResult) error {
+	outFile := files.NewFile(result.OutFile)
+
+	size, err := outFile.Size()
+
+	if err != nil {
+		return errors.Wrap(err, "Getting output file size")
+	}
+
+	modTime, err := getModTime(outFile)
+
+	if err != nil {
+		return errors.Wrap(err, "Getting output file modification time")
+	}
+
+	return j.update(task, func(record *JournalRecord) {
+		record.State = JournalTaskDone
+		record.ResultFile = outFile.FullPath()
+		record.OutputSize = size
+		record.OutputModTime = modTime
+		record.Quality = result.Quality
+		record.VideoQuality = result.VideoQuality
+		record.SavedBytes = result.SavedBytes()
+		record.Error = ""
+	})
+}
+
+// MarkFailed _
+func (j *Journal) MarkFailed(task Task, taskErr error) error {
+	return j.update(task, func(record *JournalRecord) {
+		record.State = JournalTaskFailed
+		record.ResultFile = ""
+		record.OutputSize = 0
+		record.OutputModTime = time.Time{}
+		record.Quality = nil
+		record.VideoQuality = 0
+		record.SavedBytes = 0
+
+		if taskErr != nil {
+			record.Error = taskErr.Error()
+		}
+	})
+}
+
+// MarkSkipped _
+func (j *Journal) MarkSkipped(task Task) error {
+	return j.update(task, func(record *JournalRecord) {
+		record.State = JournalTaskSkipped
+		record.ResultFile = ""
+		record.OutputSize = 0
+		record.OutputModTime = time.Time{}
+		record.Quality = nil
+		record.VideoQuality = 0
+		record.SavedBytes = 0
+		record.Error = ""
+	})
+}
+
+func (j *Journal) update(task Task, modify func(record *JournalRecord)) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	record, ok := j.content.Tasks[task.ID]
+
+	if !ok {
+		record = &JournalRecord{ID: task.ID}
+		j.content.Tasks[task.ID] = record
+	}
+
+	record.InFile = task.InFile
+	record.OutFile = task.OutFile
+	record.UpdatedAt = time.Now()
+
+	modify(record)
+
+	return j.save()
+}
+
+// save writes journal to temp file & then moves it to journal path,
+// so journal can't be broken by interruption in the middle of writing
+func (j *Journal) save() error {
+	d, err := yaml.Marshal(&j.content)
+
+	if err != nil {
+		return errors.Wrap(err, "Exporting journal to YAML")
+	}
+
+	tmpFile := j.file.NewWithSuffix("_tmp")
+
+	err = tmpFile.Create()
+
+	if err != nil {
+		return errors.Wrap(err, "Creating temp journal file")
+	}
+
+	writer, err := tmpFile.WriteContent()
+
+	if err != nil {
+		return errors.Wrap(err, "Building temp journal file writer")
+	}
+
+	_, err = writer.Write(d)
+	writer.Close()
+
+	if err != nil {
+		return errors.Wrap(err, "Writing temp journal file")
+	}
+
+	err = tmpFile.Move(j.file.FullPath())
+
+	if err != nil {
+		return errors.Wrap(err, "Moving temp journal file")
+	}
+
+	return nil
+}
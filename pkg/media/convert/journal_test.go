@@ -0,0 +1,81 @@
+package convert_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/wailorman/fftb/pkg/files"
+	"github.com/wailorman/fftb/pkg/media/convert"
+)
+
+func Test__Journal(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpDir, err := ioutil.TempDir("", "fftb_journal_test")
+	assert.Nil(err)
+	defer os.RemoveAll(tmpDir)
+
+	outPath := files.NewPath(tmpDir)
+	journalFile := outPath.BuildFile(convert.DefaultJournalFileName)
+
+	doneTask := convert.Task{
+		ID:      "0",
+		InFile:  outPath.BuildFile("in/video01.mp4").FullPath(),
+		OutFile: outPath.BuildFile("video01.mp4").FullPath(),
+	}
+
+	failedTask := convert.Task{
+		ID:      "1",
+		InFile:  outPath.BuildFile("in/video02.mp4").FullPath(),
+		OutFile: outPath.BuildFile("video02.mp4").FullPath(),
+	}
+
+	outFile := files.NewFile(doneTask.OutFile)
+	assert.Nil(outFile.Create())
+	writer, err := outFile.WriteContent()
+	assert.Nil(err)
+	writer.WriteString("converted video")
+	writer.Close()
+
+	journal := convert.NewJournal(journalFile)
+	assert.Nil(journal.Load())
+
+	assert.Nil(journal.MarkPending(doneTask))
+	assert.Nil(journal.MarkRunning(doneTask))
+	assert.Nil(journal.MarkDone(doneTask, convert.TaskResult{OutFile: doneTask.OutFile}))
+
+	assert.Nil(journal.MarkPending(failedTask))
+	assert.Nil(journal.MarkFailed(failedTask, errors.New("ffmpeg failed")))
+
+	reloadedJournal := convert.NewJournal(journalFile)
+	assert.Nil(reloadedJournal.Load())
+
+	doneRecord := reloadedJournal.Record(doneTask)
+	assert.NotNil(doneRecord)
+	assert.Equal(convert.JournalTaskDone, doneRecord.State)
+	assert.Equal(15, doneRecord.OutputSize)
+	assert.False(doneRecord.OutputModTime.IsZero())
+	assert.True(reloadedJournal.IsDone(doneTask))
+
+	changedAt := doneRecord.OutputModTime.Add(time.Minute)
+	assert.Nil(os.Chtimes(doneTask.OutFile, changedAt, changedAt))
+	assert.False(reloadedJournal.IsDone(doneTask), "changed output should not be treated as done")
+	assert.Nil(os.Chtimes(doneTask.OutFile, doneRecord.OutputModTime, doneRecord.OutputModTime))
+
+	failedRecord := reloadedJournal.Record(failedTask)
+	assert.NotNil(failedRecord)
+	assert.Equal(convert.JournalTaskFailed, failedRecord.State)
+	assert.Equal("ffmpeg failed", failedRecord.Error)
+	assert.False(reloadedJournal.IsDone(failedTask))
+
+	movedTask := doneTask
+	movedTask.OutFile = outPath.BuildFile("other/video01.mp4").FullPath()
+	assert.False(reloadedJournal.IsDone(movedTask), "task with another output should not be resumed")
+
+	assert.Nil(outFile.Remove())
+	assert.False(reloadedJournal.IsDone(doneTask), "removed output should not be treated as done")
+}
@@ -0,0 +1,54 @@
+package convert
+
+import (
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/wailorman/fftb/pkg/files"
+	ffmpegModels "github.com/wailorman/fftb/pkg/goffmpeg/models"
+)
+
+// CreationTimeLayout is a format of creation_time container tag
+const CreationTimeLayout = "2006-01-02T15:04:05.000000Z"
+
+// metadataCopy maps global metadata of input container to output one and sets
+// creation_time from input file modification time (which can be restored by `fftb etime`)
+type metadataCopy struct {
+	task    Task
+	modTime time.Time
+}
+
+func newMetadataCopy(task Task, modTime time.Time) *metadataCopy {
+	return &metadataCopy{
+		task:    task,
+		modTime: modTime,
+	}
+}
+
+func (mc *metadataCopy) configure(mediaFile *ffmpegModels.Mediafile) error {
+	if mc.task.Params.SkipMetadataCopy {
+		return nil
+	}
+
+	mediaFile.SetMapMetadata("0")
+
+	if !mc.modTime.IsZero() {
+		mediaFile.SetTags(map[string]string{
+			"creation_time": mc.modTime.UTC().Format(CreationTimeLayout),
+		})
+	}
+
+	return nil
+}
+
+// getModTime returns file modification time
+func getModTime(file files.Filer) (time.Time, error) {
+	info, err := os.Stat(file.FullPath())
+
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "Getting file info")
+	}
+
+	return info.ModTime(), nil
+}
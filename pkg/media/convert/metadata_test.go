@@ -0,0 +1,46 @@
+package convert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test__metadataCopy(t *testing.T) {
+	modTime := time.Date(2020, 10, 5, 21, 30, 15, 0, time.FixedZone("MSK", 3*60*60))
+
+	type testCase struct {
+		name   string
+		params Params
+		want   []string
+	}
+
+	cases := []testCase{
+		{
+			name: "default",
+			want: []string{
+				"-i", "in.mp4",
+				"-map_metadata", "0",
+				"-metadata", "creation_time=2020-10-05T18:30:15.000000Z",
+				"out.mp4",
+			},
+		},
+		{
+			name:   "skipped",
+			params: Params{SkipMetadataCopy: true},
+			want:   []string{"-i", "in.mp4", "out.mp4"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mediaFile := newTestMediaFile()
+
+			err := newMetadataCopy(Task{Params: c.params}, modTime).configure(mediaFile)
+
+			assert.Nil(t, err)
+			assert.Equal(t, c.want, mediaFile.ToStrCommand())
+		})
+	}
+}
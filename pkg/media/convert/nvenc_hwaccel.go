@@ -26,6 +26,8 @@ func (hw *nvencHWAccel) configure(mediaFile *ffmpegModels.Mediafile) error {
 		mediaFile.SetInputVideoCodec("hevc_cuvid")
 	case "h264":
 		mediaFile.SetInputVideoCodec("h264_cuvid")
+	case "av1":
+		mediaFile.SetInputVideoCodec("av1_cuvid")
 	}
 
 	mediaFile.SetNvencRateControl("constqp")
@@ -41,6 +43,15 @@ func (hw *nvencHWAccel) configure(mediaFile *ffmpegModels.Mediafile) error {
 		mediaFile.SetVideoCodec("hevc_nvenc")
 	case H264CodecType:
 		mediaFile.SetVideoCodec("h264_nvenc")
+	case Av1CodecType:
+		// CPU encoder options should not be passed to NVENC
+		mediaFile.SetVideoCodec("av1_nvenc")
+		mediaFile.SetPreset(hw.task.Params.Preset)
+		mediaFile.SetCPUUsed(0)
+
+		if hw.task.Params.VideoQuality > 0 {
+			mediaFile.SetVideoBitRate("")
+		}
 	default:
 		return ErrCodecIsNotSupportedByEncoder
 	}
@@ -0,0 +1,132 @@
+package convert
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/wailorman/fftb/pkg/files"
+)
+
+const (
+	// OverwriteOutputConflictPolicy _
+	OverwriteOutputConflictPolicy = "overwrite"
+	// SkipOutputConflictPolicy _
+	SkipOutputConflictPolicy = "skip"
+	// RenameOutputConflictPolicy _
+	RenameOutputConflictPolicy = "rename"
+	// FailOutputConflictPolicy _
+	FailOutputConflictPolicy = "fail"
+)
+
+// maxRenameAttempts limits suffixes search for rename policy
+const maxRenameAttempts = 1000
+
+// OutputCollision describes output file which is already exists or used by more than one task
+type OutputCollision struct {
+	OutFile string   `yaml:"out_file"`
+	TaskIDs []string `yaml:"task_ids"`
+	Exists  bool     `yaml:"exists"`
+	Policy  string   `yaml:"policy"`
+}
+
+// FindOutputCollisions returns list of output files which will conflict while conversion
+func FindOutputCollisions(tasks []Task) []OutputCollision {
+	taskIDsByOutFile := make(map[string][]string)
+	policyByOutFile := make(map[string]string)
+	outFiles := make([]string, 0)
+
+	for _, task := range tasks {
+		if task.Params.ReplaceOriginal {
+			continue
+		}
+
+		if _, ok := taskIDsByOutFile[task.OutFile]; !ok {
+			outFiles = append(outFiles, task.OutFile)
+			policyByOutFile[task.OutFile] = outputConflictPolicy(task)
+		}
+
+		taskIDsByOutFile[task.OutFile] = append(taskIDsByOutFile[task.OutFile], task.ID)
+	}
+
+	sort.Strings(outFiles)
+
+	collisions := make([]OutputCollision, 0)
+
+	for _, outFile := range outFiles {
+		taskIDs := taskIDsByOutFile[outFile]
+		exists := isOutputTaken(files.NewFile(outFile))
+
+		if len(taskIDs) > 1 || exists {
+			collisions = append(collisions, OutputCollision{
+				OutFile: outFile,
+				TaskIDs: taskIDs,
+				Exists:  exists,
+				Policy:  policyByOutFile[outFile],
+			})
+		}
+	}
+
+	return collisions
+}
+
+// resolveOutputConflict applies task's output conflict policy.
+// Returns task with output file which should be used for conversion
+func resolveOutputConflict(task Task, isTaken func(file files.Filer) bool) (Task, error) {
+	outFile := files.NewFile(task.OutFile)
+
+	if !isTaken(outFile) {
+		return task, nil
+	}
+
+	switch outputConflictPolicy(task) {
+	case OverwriteOutputConflictPolicy:
+		if isDirectory(outFile) {
+			return task, ErrOutputFileExistsOrIsDirectory
+		}
+
+		return task, nil
+
+	case SkipOutputConflictPolicy:
+		return task, errors.Wrap(ErrTaskSkipped, "Output file already exists")
+
+	case FailOutputConflictPolicy:
+		return task, ErrOutputFileExistsOrIsDirectory
+
+	case RenameOutputConflictPolicy:
+		for i := 1; i <= maxRenameAttempts; i++ {
+			renamedFile := outFile.NewWithSuffix(fmt.Sprintf("_%d", i))
+
+			if !isTaken(renamedFile) {
+				task.OutFile = renamedFile.FullPath()
+				return task, nil
+			}
+		}
+
+		return task, errors.Wrap(ErrOutputFileExistsOrIsDirectory, "Searching free file name")
+
+	default:
+		return task, ErrUnsupportedOutputConflictPolicy
+	}
+}
+
+func outputConflictPolicy(task Task) string {
+	if task.Params.OutputConflictPolicy == "" {
+		return OverwriteOutputConflictPolicy
+	}
+
+	return task.Params.OutputConflictPolicy
+}
+
+func isOutputTaken(file files.Filer) bool {
+	_, err := os.Stat(file.FullPath())
+
+	return err == nil
+}
+
+func isDirectory(file files.Filer) bool {
+	info, err := os.Stat(file.FullPath())
+
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,118 @@
+package convert
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/wailorman/fftb/pkg/files"
+)
+
+func Test__resolveOutputConflict(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpDir, err := ioutil.TempDir("", "fftb_output_conflict_test")
+	assert.Nil(err)
+	defer os.RemoveAll(tmpDir)
+
+	outPath := files.NewPath(tmpDir)
+	existingFile := outPath.BuildFile("video01.mp4")
+	assert.Nil(existingFile.Create())
+	assert.Nil(existingFile.NewWithSuffix("_1").Create())
+
+	testTable := []struct {
+		policy          string
+		outFile         string
+		expectedOutFile string
+		expectedErr     error
+	}{
+		{
+			policy:          "",
+			outFile:         existingFile.FullPath(),
+			expectedOutFile: existingFile.FullPath(),
+		},
+		{
+			policy:          OverwriteOutputConflictPolicy,
+			outFile:         existingFile.FullPath(),
+			expectedOutFile: existingFile.FullPath(),
+		},
+		{
+			policy:          OverwriteOutputConflictPolicy,
+			outFile:         tmpDir,
+			expectedOutFile: tmpDir,
+			expectedErr:     ErrOutputFileExistsOrIsDirectory,
+		},
+		{
+			policy:          SkipOutputConflictPolicy,
+			outFile:         existingFile.FullPath(),
+			expectedOutFile: existingFile.FullPath(),
+			expectedErr:     ErrTaskSkipped,
+		},
+		{
+			policy:          FailOutputConflictPolicy,
+			outFile:         existingFile.FullPath(),
+			expectedOutFile: existingFile.FullPath(),
+			expectedErr:     ErrOutputFileExistsOrIsDirectory,
+		},
+		{
+			policy:          RenameOutputConflictPolicy,
+			outFile:         existingFile.FullPath(),
+			expectedOutFile: outPath.BuildFile("video01_2.mp4").FullPath(),
+		},
+		{
+			policy:          FailOutputConflictPolicy,
+			outFile:         outPath.BuildFile("video02.mp4").FullPath(),
+			expectedOutFile: outPath.BuildFile("video02.mp4").FullPath(),
+		},
+	}
+
+	for i, testItem := range testTable {
+		task := Task{
+			OutFile: testItem.outFile,
+			Params:  Params{OutputConflictPolicy: testItem.policy},
+		}
+
+		resolvedTask, err := resolveOutputConflict(task, isOutputTaken)
+
+		assert.Equal(testItem.expectedErr, errors.Cause(err), i)
+		assert.Equal(testItem.expectedOutFile, resolvedTask.OutFile, i)
+	}
+}
+
+func Test__FindOutputCollisions(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpDir, err := ioutil.TempDir("", "fftb_output_conflict_test")
+	assert.Nil(err)
+	defer os.RemoveAll(tmpDir)
+
+	outPath := files.NewPath(tmpDir)
+	existingFile := outPath.BuildFile("existing.mp4")
+	assert.Nil(existingFile.Create())
+
+	tasks := []Task{
+		{ID: "0", OutFile: outPath.BuildFile("a.mp4").FullPath()},
+		{ID: "1", OutFile: outPath.BuildFile("b.mp4").FullPath()},
+		{ID: "2", OutFile: outPath.BuildFile("a.mp4").FullPath()},
+		{ID: "3", OutFile: existingFile.FullPath(), Params: Params{OutputConflictPolicy: SkipOutputConflictPolicy}},
+	}
+
+	collisions := FindOutputCollisions(tasks)
+
+	assert.Equal([]OutputCollision{
+		{
+			OutFile: outPath.BuildFile("a.mp4").FullPath(),
+			TaskIDs: []string{"0", "2"},
+			Exists:  false,
+			Policy:  OverwriteOutputConflictPolicy,
+		},
+		{
+			OutFile: existingFile.FullPath(),
+			TaskIDs: []string{"3"},
+			Exists:  true,
+			Policy:  SkipOutputConflictPolicy,
+		},
+	}, collisions)
+}
@@ -0,0 +1,72 @@
+package convert
+
+import (
+	ffmpegModels "github.com/wailorman/fftb/pkg/goffmpeg/models"
+	mediaUtils "github.com/wailorman/fftb/pkg/media/utils"
+)
+
+// qsvDecoders maps input codecs to Intel Quick Sync Video decoders
+var qsvDecoders = map[string]string{
+	"h264": "h264_qsv",
+	"hevc": "hevc_qsv",
+	"vp9":  "vp9_qsv",
+	"av1":  "av1_qsv",
+}
+
+type qsvHWAccel struct {
+	task     Task
+	metadata ffmpegModels.Metadata
+}
+
+func (hw *qsvHWAccel) configure(mediaFile *ffmpegModels.Mediafile) error {
+	if !mediaUtils.IsVideo(hw.metadata) {
+		return ErrFileIsNotVideo
+	}
+
+	device := hw.task.Params.HWDevice
+
+	if decoder, ok := qsvDecoders[mediaUtils.GetVideoCodec(hw.metadata)]; ok {
+		// decoded frames stay in GPU memory
+		mediaFile.SetHardwareAcceleration("qsv")
+		mediaFile.SetQsvDevice(device)
+		mediaFile.SetHardwareAccelerationOutputFormat("qsv")
+		mediaFile.SetInputVideoCodec(decoder)
+	} else {
+		// frames decoded by CPU should be uploaded to GPU
+		if device != "" {
+			mediaFile.SetInitHardwareDevice("qsv=hw:hw,child_device=" + device)
+		} else {
+			mediaFile.SetInitHardwareDevice("qsv=hw")
+		}
+
+		mediaFile.SetFilterHardwareDevice("hw")
+		mediaFile.SetVideoFilter("format=nv12,hwupload=extra_hw_frames=64")
+	}
+
+	// QSV presets have same names as x264 presets (veryfast ... veryslow)
+	mediaFile.SetPreset(hw.task.Params.Preset)
+	resetCPUEncoderOptions(mediaFile)
+
+	if hw.task.Params.VideoQuality > 0 {
+		mediaFile.SetVideoBitRate("")
+		mediaFile.SetConstantQuantization(0)
+		mediaFile.SetGlobalQuality(hw.task.Params.VideoQuality)
+	}
+
+	switch hw.task.Params.VideoCodec {
+	case HevcCodecType:
+		mediaFile.SetVideoCodec("hevc_qsv")
+	case H264CodecType:
+		mediaFile.SetVideoCodec("h264_qsv")
+	case Av1CodecType:
+		mediaFile.SetVideoCodec("av1_qsv")
+	default:
+		return ErrCodecIsNotSupportedByEncoder
+	}
+
+	return nil
+}
+
+func (hw *qsvHWAccel) getType() string {
+	return QsvHWAccelType
+}
@@ -0,0 +1,278 @@
+package convert
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/wailorman/fftb/pkg/files"
+	"github.com/wailorman/fftb/pkg/media/minfo"
+	"github.com/wailorman/fftb/pkg/media/quality"
+	"github.com/wailorman/fftb/pkg/media/segm"
+)
+
+const (
+	// qualitySearchSampleSec is a duration of each sample segment
+	qualitySearchSampleSec = 10
+	// qualitySearchSamples is a number of sample segments encoded for each checked quality value
+	qualitySearchSamples = 3
+	// MinSearchVideoQuality is the best quality value checked while searching
+	MinSearchVideoQuality = 15
+	// MaxSearchVideoQuality is the worst quality value checked while searching
+	MaxSearchVideoQuality = 45
+)
+
+// QualitySearch finds video quality value (CRF/QP) for the task which gives target VMAF score
+type QualitySearch struct {
+	ctx        context.Context
+	infoGetter minfo.Getter
+	// encoder converts sample task, can be replaced in tests
+	encoder func(task Task) error
+}
+
+// NewQualitySearch _
+func NewQualitySearch(ctx context.Context) *QualitySearch {
+	qs := &QualitySearch{
+		ctx: ctx,
+		// samples are temporary files, so they shouldn't get into persistent media info cache
+		infoGetter: minfo.New(),
+	}
+
+	qs.encoder = qs.encode
+
+	return qs
+}
+
+// NeedsQualitySearch returns true if video quality of the task should be chosen by target VMAF score
+func NeedsQualitySearch(task Task) bool {
+	return task.Params.TargetVMAF > 0 && task.Params.VideoQuality == 0 && task.Params.TargetSize == ""
+}
+
+// Search encodes few short samples of input file & returns the worst (maximum) video quality value
+// which gives VMAF score not lower than target
+func (qs *QualitySearch) Search(task Task) (int, error) {
+	measurer, err := quality.New(qs.ctx, []string{quality.VmafMetric})
+
+	if err != nil {
+		return 0, errors.Wrap(err, "Building quality measurer")
+	}
+
+	tmpDir, err := ioutil.TempDir("", "fftb_quality_search")
+
+	if err != nil {
+		return 0, errors.Wrap(err, "Creating temp directory")
+	}
+
+	defer os.RemoveAll(tmpDir)
+
+	tmpPath := files.NewPath(tmpDir)
+
+	samples, err := qs.extractSamples(files.NewFile(task.InFile), tmpPath)
+
+	if err != nil {
+		return 0, errors.Wrap(err, "Extracting samples")
+	}
+
+	return bisectVideoQuality(MinSearchVideoQuality, MaxSearchVideoQuality, task.Params.TargetVMAF, func(videoQuality int) (float64, error) {
+		return qs.score(task, samples, videoQuality, tmpPath, measurer)
+	})
+}
+
+// extractSamples copies few evenly distributed fragments of input file to temp path without encoding
+func (qs *QualitySearch) extractSamples(inFile files.Filer, tmpPath files.Pather) ([]*segm.Segment, error) {
+	metadata, err := qs.infoGetter.GetMediaInfo(inFile)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "Getting file metadata")
+	}
+
+	segmentsCount := int(math.Ceil(metadata.DurationSeconds() / qualitySearchSampleSec))
+	samples := make([]*segm.Segment, 0, qualitySearchSamples)
+
+	for _, position := range pickSamples(segmentsCount, qualitySearchSamples) {
+		sample, err := qs.extractSample(inFile, tmpPath, position)
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "Extracting sample #%d", position)
+		}
+
+		samples = append(samples, sample)
+	}
+
+	return samples, nil
+}
+
+// extractSample slices only sample range of input file, so the rest of file is not copied
+func (qs *QualitySearch) extractSample(inFile files.Filer, tmpPath files.Pather, position int) (*segm.Segment, error) {
+	sliceOperation := segm.NewSliceOperation(qs.ctx)
+
+	err := sliceOperation.Init(segm.SliceRequest{
+		InFile:      inFile,
+		OutPath:     tmpPath,
+		SegmentSec:  qualitySearchSampleSec,
+		StartSec:    position * qualitySearchSampleSec,
+		DurationSec: qualitySearchSampleSec,
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "Initializing slice operation")
+	}
+
+	progress, segments, failures := sliceOperation.Run()
+	var sample *segm.Segment
+
+	for {
+		select {
+		case <-progress:
+
+		case segment, ok := <-segments:
+			// sample range can be split into few segments by keyframes, first one is enough
+			if ok && sample == nil {
+				sample = &segm.Segment{Position: position, File: segment.File}
+			}
+
+		case failure, failed := <-failures:
+			if failed {
+				return nil, failure
+			}
+
+			// segments are sent before failures channel is closed
+			<-sliceOperation.Closed()
+
+			if sample == nil {
+				return nil, errors.New("Slice operation returned no segments")
+			}
+
+			return sample, nil
+		}
+	}
+}
+
+// score returns average VMAF score of samples encoded with given video quality value
+func (qs *QualitySearch) score(
+	task Task,
+	samples []*segm.Segment,
+	videoQuality int,
+	tmpPath files.Pather,
+	measurer quality.Measurer,
+) (float64, error) {
+	var sum float64
+
+	for _, sample := range samples {
+		sampleTask := task
+		sampleTask.InFile = sample.File.FullPath()
+		sampleTask.OutFile = tmpPath.BuildFile(fmt.Sprintf("sample_%d_q%d%s", sample.Position, videoQuality, sample.File.Extension())).FullPath()
+		sampleTask.Params = sampleParams(task.Params, videoQuality)
+
+		if err := qs.encoder(sampleTask); err != nil {
+			return 0, errors.Wrapf(err, "Encoding sample #%d", sample.Position)
+		}
+
+		scores, err := measurer.Measure(sample.File, files.NewFile(sampleTask.OutFile))
+
+		if err != nil {
+			return 0, errors.Wrapf(err, "Measuring sample #%d quality", sample.Position)
+		}
+
+		sum += scores.VMAF
+	}
+
+	return sum / float64(len(samples)), nil
+}
+
+// sampleParams keeps only encoding params of task. Sample should be always encoded & kept in temp directory:
+// no output file policies, replacement of input file or quality verification
+func sampleParams(params Params, videoQuality int) Params {
+	params.VideoQuality = videoQuality
+	params.TargetVMAF = 0
+	params.TwoPass = false
+	params.VerifyQuality = nil
+	params.OutputConflictPolicy = OverwriteOutputConflictPolicy
+
+	params.AlreadyEncodedPolicy = ReencodeAlreadyEncodedPolicy
+	params.CompareBitRate = false
+	params.KeepIfSmaller = false
+	params.MaxSizeRatio = 0
+	params.LargerOutputPolicy = ""
+	params.ReplaceOriginal = false
+	params.TrashPath = ""
+	params.SkipMetadataCopy = true
+
+	return params
+}
+
+func (qs *QualitySearch) encode(task Task) error {
+	converter := NewConverter(qs.ctx, qs.infoGetter)
+	progress, failures := converter.Convert(task)
+
+	for {
+		select {
+		case <-progress:
+
+		case failure, failed := <-failures:
+			if !failed {
+				<-converter.Closed()
+				return nil
+			}
+
+			return failure
+		}
+	}
+}
+
+// pickSamples returns positions of evenly distributed segments.
+// Last segment is usually shorter, so it's not used
+func pickSamples(segmentsCount, count int) []int {
+	if segmentsCount > 1 {
+		segmentsCount--
+	}
+
+	if segmentsCount < 1 {
+		segmentsCount = 1
+	}
+
+	positions := make([]int, 0, count)
+
+	if segmentsCount <= count {
+		for i := 0; i < segmentsCount; i++ {
+			positions = append(positions, i)
+		}
+
+		return positions
+	}
+
+	for i := 1; i <= count; i++ {
+		positions = append(positions, i*segmentsCount/(count+1))
+	}
+
+	return positions
+}
+
+// bisectVideoQuality returns maximum video quality value (the smaller value is the better quality)
+// with score not lower than target. Returns minQuality if none of values reaches target
+func bisectVideoQuality(minQuality, maxQuality int, target float64, score func(videoQuality int) (float64, error)) (int, error) {
+	best := minQuality
+	low, high := minQuality, maxQuality
+
+	for low <= high {
+		middle := (low + high) / 2
+
+		value, err := score(middle)
+
+		if err != nil {
+			return 0, err
+		}
+
+		if value >= target {
+			best = middle
+			low = middle + 1
+		} else {
+			high = middle - 1
+		}
+	}
+
+	return best, nil
+}
@@ -0,0 +1,122 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/wailorman/fftb/pkg/files"
+	"github.com/wailorman/fftb/pkg/media/quality"
+	"github.com/wailorman/fftb/pkg/media/segm"
+)
+
+func Test__bisectVideoQuality(t *testing.T) {
+	assert := assert.New(t)
+
+	// each quality step reduces VMAF by 1 point
+	linearScore := func(videoQuality int) (float64, error) {
+		return 120 - float64(videoQuality), nil
+	}
+
+	testTable := []struct {
+		target          float64
+		expectedQuality int
+	}{
+		{target: 95, expectedQuality: 25},
+		{target: 94.5, expectedQuality: 25},
+		{target: 60, expectedQuality: MaxSearchVideoQuality},
+		{target: 110, expectedQuality: MinSearchVideoQuality},
+	}
+
+	for i, testItem := range testTable {
+		checked := 0
+
+		videoQuality, err := bisectVideoQuality(MinSearchVideoQuality, MaxSearchVideoQuality, testItem.target, func(videoQuality int) (float64, error) {
+			checked++
+			return linearScore(videoQuality)
+		})
+
+		assert.Nil(err, i)
+		assert.Equal(testItem.expectedQuality, videoQuality, i)
+		assert.True(checked <= 5, i)
+	}
+
+	scoreErr := errors.New("ffmpeg failed")
+
+	_, err := bisectVideoQuality(MinSearchVideoQuality, MaxSearchVideoQuality, 95, func(int) (float64, error) {
+		return 0, scoreErr
+	})
+
+	assert.Equal(scoreErr, err)
+}
+
+func Test__pickSamples(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal([]int{2, 5, 8}, pickSamples(12, 3))
+	assert.Equal([]int{0, 1}, pickSamples(3, 3))
+	assert.Equal([]int{0}, pickSamples(1, 3))
+	assert.Equal([]int{0}, pickSamples(0, 3))
+}
+
+type constantMeasurer struct {
+	score float64
+}
+
+func (m *constantMeasurer) Measure(reference, distorted files.Filer) (quality.Scores, error) {
+	return quality.Scores{VMAF: m.score}, nil
+}
+
+func Test__QualitySearch__score(t *testing.T) {
+	assert := assert.New(t)
+
+	encodedTasks := make([]Task, 0)
+
+	qs := &QualitySearch{
+		encoder: func(task Task) error {
+			encodedTasks = append(encodedTasks, task)
+			return nil
+		},
+	}
+
+	task := Task{
+		InFile:  "in.mp4",
+		OutFile: "out.mp4",
+		Params: Params{
+			VideoCodec:           "hevc",
+			TargetVMAF:           93,
+			ReplaceOriginal:      true,
+			TrashPath:            "/trash",
+			KeepIfSmaller:        true,
+			MaxSizeRatio:         0.9,
+			LargerOutputPolicy:   DeleteLargerOutputPolicy,
+			AlreadyEncodedPolicy: SkipAlreadyEncodedPolicy,
+			CompareBitRate:       true,
+			VerifyQuality:        []string{quality.VmafMetric},
+		},
+	}
+
+	samples := []*segm.Segment{
+		{Position: 1, File: files.NewFile("/tmp/samples/1.mp4")},
+		{Position: 5, File: files.NewFile("/tmp/samples/5.mp4")},
+	}
+
+	score, err := qs.score(task, samples, 30, files.NewPath("/tmp/samples"), &constantMeasurer{score: 94})
+
+	assert.Nil(err)
+	assert.Equal(94.0, score)
+
+	if assert.Len(encodedTasks, 2) {
+		sampleTask := encodedTasks[0]
+
+		assert.Equal("/tmp/samples/1.mp4", sampleTask.InFile)
+		assert.Equal("/tmp/samples/sample_1_q30.mp4", sampleTask.OutFile)
+		assert.Equal(Params{
+			VideoCodec:           "hevc",
+			VideoQuality:         30,
+			OutputConflictPolicy: OverwriteOutputConflictPolicy,
+			AlreadyEncodedPolicy: ReencodeAlreadyEncodedPolicy,
+			SkipMetadataCopy:     true,
+		}, sampleTask.Params)
+	}
+}
@@ -1,7 +1,12 @@
 package convert
 
 import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/wailorman/fftb/pkg/files"
@@ -28,34 +33,121 @@ type RecursiveTask struct {
 	InPath      files.Pather
 	OutPath     files.Pather
 	Params      Params
+	// Flatten puts all output files directly to OutPath instead of mirroring InPath subdirectories
+	Flatten bool
+	// Filter rejects files by name before probing them with ffprobe
+	Filter files.FilesFilter
+	// ProbeParallelism is a number of parallel ffprobe workers. By default equals to number of CPUs
+	ProbeParallelism int
+	// Resume keeps conversion journal in OutPath, so tasks converted in previous runs are skipped
+	Resume bool
 }
 
 // BuildBatchTaskFromRecursive _
 func BuildBatchTaskFromRecursive(task RecursiveTask, infoGetter minfo.Getter) (BatchTask, error) {
-	allFiles, err := task.InPath.Files()
+	allFiles, err := task.InPath.FilesWithFilter(task.Filter)
 
 	if err != nil {
 		return BatchTask{}, errors.Wrap(err, "Getting files from path")
 	}
 
-	videoFiles := mediaUtils.FilterVideos(allFiles, infoGetter)
+	probeParallelism := task.ProbeParallelism
+
+	if probeParallelism < 1 {
+		probeParallelism = runtime.NumCPU()
+	}
+
+	candidates := make([]files.Filer, 0, len(allFiles))
+
+	for _, file := range allFiles {
+		if !IsReplaceTempFile(file) {
+			candidates = append(candidates, file)
+		}
+	}
+
+	videoFiles := mediaUtils.FilterVideos(candidates, infoGetter, probeParallelism)
 
 	batchTask := BatchTask{
 		Parallelism: task.Parallelism,
+		Resume:      task.Resume,
 		Tasks:       make([]Task, 0),
 	}
 
-	for i, file := range videoFiles {
-		outFile := file.Clone()
-		outFile.SetDirPath(task.OutPath)
+	if task.Resume {
+		batchTask.JournalPath = task.OutPath.BuildFile(DefaultJournalFileName).FullPath()
+	}
 
+	outFiles, err := buildRecursiveOutFiles(task, videoFiles)
+
+	if err != nil {
+		return BatchTask{}, errors.Wrap(err, "Building output file paths")
+	}
+
+	for i, file := range videoFiles {
 		batchTask.Tasks = append(batchTask.Tasks, Task{
 			ID:      strconv.Itoa(i),
 			InFile:  file.FullPath(),
-			OutFile: outFile.FullPath(),
+			OutFile: outFiles[i].FullPath(),
 			Params:  task.Params,
 		})
 	}
 
+	batchTask.PlannedCollisions = FindOutputCollisions(batchTask.Tasks)
+
 	return batchTask, nil
 }
+
+// buildRecursiveOutFiles mirrors input files subdirectories in output path.
+// In flatten mode files with same names are renamed in deterministic way:
+// first file (in lexical order) keeps its name, next ones receive
+// relative subdirectory suffix (in/game2/a.mp4 -> out/a_game2.mp4) and numeric suffix if it's not enough
+func buildRecursiveOutFiles(task RecursiveTask, inFiles []files.Filer) ([]files.Filer, error) {
+	outFiles := make([]files.Filer, len(inFiles))
+	takenPaths := make(map[string]bool)
+
+	order := make([]int, len(inFiles))
+
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return inFiles[order[i]].FullPath() < inFiles[order[j]].FullPath()
+	})
+
+	for _, i := range order {
+		file := inFiles[i]
+
+		relDir, err := filepath.Rel(task.InPath.FullPath(), filepath.Dir(file.FullPath()))
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "Getting relative path of `%s`", file.FullPath())
+		}
+
+		outFile := file.Clone()
+
+		if task.Flatten || relDir == "." {
+			outFile.SetDirPath(task.OutPath)
+		} else {
+			outFile.SetDirPath(task.OutPath.BuildSubpath(relDir))
+		}
+
+		if task.Flatten && takenPaths[outFile.FullPath()] && relDir != "." {
+			dirSuffix := strings.ReplaceAll(filepath.ToSlash(relDir), "/", "_")
+			outFile = outFile.NewWithSuffix("_" + dirSuffix)
+		}
+
+		for n := 1; takenPaths[outFile.FullPath()]; n++ {
+			candidate := outFile.NewWithSuffix(fmt.Sprintf("_%d", n))
+
+			if !takenPaths[candidate.FullPath()] {
+				outFile = candidate
+			}
+		}
+
+		takenPaths[outFile.FullPath()] = true
+		outFiles[i] = outFile
+	}
+
+	return outFiles, nil
+}
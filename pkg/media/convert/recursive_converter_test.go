@@ -0,0 +1,100 @@
+package convert_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wailorman/fftb/pkg/files"
+	ffmpegModels "github.com/wailorman/fftb/pkg/goffmpeg/models"
+	"github.com/wailorman/fftb/pkg/media/convert"
+	"github.com/wailorman/fftb/pkg/media/minfo"
+)
+
+type videoInfoGetterStub struct {
+}
+
+func (ig *videoInfoGetterStub) GetMediaInfo(file files.Filer) (ffmpegModels.Metadata, error) {
+	return ffmpegModels.Metadata{
+		Streams: []ffmpegModels.Streams{
+			{CodecType: "video", CodecName: "h264", BitRate: "1000"},
+		},
+	}, nil
+}
+
+func (ig *videoInfoGetterStub) GetFramesSummary(file files.Filer) (minfo.FramesSummary, error) {
+	return minfo.FramesSummary{}, nil
+}
+
+func (ig *videoInfoGetterStub) GetFramesList(file files.Filer) (chan bool, chan ffmpegModels.Framer, chan error) {
+	return nil, nil, nil
+}
+
+func Test__BuildBatchTaskFromRecursive(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpDir, err := ioutil.TempDir("", "fftb_recursive_test")
+	assert.Nil(err)
+	defer os.RemoveAll(tmpDir)
+
+	inPath := files.NewPath(tmpDir).BuildSubpath("in")
+	outPath := files.NewPath(tmpDir).BuildSubpath("out")
+
+	for _, name := range []string{"a.mp4", "game1/a.mp4", "game2/a.mp4", "game2/b.mp4", "game2/nested/a.mp4"} {
+		assert.Nil(inPath.BuildFile(name).Create())
+	}
+
+	// a_game1.mp4 is taken by real file, so game1/a.mp4 should receive numeric suffix in flatten mode
+	assert.Nil(inPath.BuildFile("a_game1.mp4").Create())
+
+	testTable := []struct {
+		flatten          bool
+		expectedOutFiles map[string]string
+	}{
+		{
+			flatten: false,
+			expectedOutFiles: map[string]string{
+				"a.mp4":              "a.mp4",
+				"game1/a.mp4":        "game1/a.mp4",
+				"game2/a.mp4":        "game2/a.mp4",
+				"game2/b.mp4":        "game2/b.mp4",
+				"game2/nested/a.mp4": "game2/nested/a.mp4",
+				"a_game1.mp4":        "a_game1.mp4",
+			},
+		},
+		{
+			flatten: true,
+			expectedOutFiles: map[string]string{
+				"a.mp4":              "a.mp4",
+				"a_game1.mp4":        "a_game1.mp4",
+				"game1/a.mp4":        "a_game1_1.mp4",
+				"game2/a.mp4":        "a_game2.mp4",
+				"game2/b.mp4":        "b.mp4",
+				"game2/nested/a.mp4": "a_game2_nested.mp4",
+			},
+		},
+	}
+
+	for _, testItem := range testTable {
+		batchTask, err := convert.BuildBatchTaskFromRecursive(convert.RecursiveTask{
+			Parallelism: 1,
+			InPath:      inPath,
+			OutPath:     outPath,
+			Flatten:     testItem.flatten,
+		}, &videoInfoGetterStub{})
+
+		assert.Nil(err)
+		assert.Len(batchTask.Tasks, len(testItem.expectedOutFiles))
+		assert.Empty(batchTask.PlannedCollisions, "flatten: %v", testItem.flatten)
+
+		for _, task := range batchTask.Tasks {
+			inFile := files.NewFile(task.InFile)
+			relInFile := task.InFile[len(inPath.FullPath())+1:]
+			expectedOutFile, ok := testItem.expectedOutFiles[relInFile]
+
+			assert.True(ok, "unexpected input file: %s", inFile.FullPath())
+			assert.Equal(outPath.BuildFile(expectedOutFile).FullPath(), task.OutFile, "flatten: %v", testItem.flatten)
+		}
+	}
+}
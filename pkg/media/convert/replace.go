@@ -0,0 +1,208 @@
+package convert
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/wailorman/fftb/pkg/files"
+	"github.com/wailorman/fftb/pkg/goffmpeg/ffmpeg"
+	ffmpegModels "github.com/wailorman/fftb/pkg/goffmpeg/models"
+	"github.com/wailorman/fftb/pkg/media/minfo"
+)
+
+// ReplaceTempSuffix is added to temporary output file which is placed beside original file
+const ReplaceTempSuffix = ".fftb_tmp"
+
+// minDurationTolerance is a max difference between original & replacement durations in seconds.
+// For long videos relative tolerance (durationToleranceRatio) is used
+const minDurationTolerance = 1.0
+
+const durationToleranceRatio = 0.01
+
+// tailCheckSeconds is a length of replacement file ending which should be decodable
+const tailCheckSeconds = 5.0
+
+// maxTrashAttempts limits suffixes search for file name in trash
+const maxTrashAttempts = 1000
+
+// replaceTempFile returns path of temporary output file for original file
+func replaceTempFile(original files.Filer) files.Filer {
+	return original.NewWithSuffix(ReplaceTempSuffix)
+}
+
+// IsReplaceTempFile returns true if file is a temporary output of replace-original mode.
+// Such files can be left after crash, so they should not be converted
+func IsReplaceTempFile(file files.Filer) bool {
+	return strings.HasSuffix(file.BaseName(), ReplaceTempSuffix)
+}
+
+// validateReplacement compares original & replacement metadata
+func validateReplacement(task Task, original, replacement ffmpegModels.Metadata) error {
+	originalDuration := original.DurationSeconds()
+	replacementDuration := replacement.DurationSeconds()
+
+	tolerance := math.Max(minDurationTolerance, originalDuration*durationToleranceRatio)
+
+	if math.Abs(originalDuration-replacementDuration) > tolerance {
+		return errors.Wrapf(
+			ErrInvalidReplacement,
+			"Duration differs: original %.2fs, converted %.2fs",
+			originalDuration,
+			replacementDuration,
+		)
+	}
+
+	originalCounts := countStreams(original)
+	replacementCounts := countStreams(replacement)
+
+	if replacementCounts["video"] < 1 {
+		return errors.Wrap(ErrInvalidReplacement, "Converted file has no video stream")
+	}
+
+	if originalCounts["audio"] > 0 && replacementCounts["audio"] < 1 && !task.Params.DropAudio {
+		return errors.Wrap(ErrInvalidReplacement, "Converted file has no audio stream")
+	}
+
+	if task.Params.KeepAllStreams && len(replacement.Streams) < len(original.Streams) {
+		return errors.Wrapf(
+			ErrInvalidReplacement,
+			"Streams count differs: original %d, converted %d",
+			len(original.Streams),
+			len(replacement.Streams),
+		)
+	}
+
+	return nil
+}
+
+func countStreams(metadata ffmpegModels.Metadata) map[string]int {
+	counts := make(map[string]int)
+
+	for _, stream := range metadata.Streams {
+		counts[stream.CodecType]++
+	}
+
+	return counts
+}
+
+// checkDecodableTail decodes last seconds of video with ffprobe.
+// Truncated or broken files produce errors or no frames at all
+func checkDecodableTail(ctx context.Context, file files.Filer, duration float64) error {
+	cfg, err := ffmpeg.Configure(ctx)
+
+	if err != nil {
+		return errors.Wrap(err, "Configuring ffprobe")
+	}
+
+	cmd := exec.CommandContext(ctx, cfg.FfprobeBin, decodableTailArgs(file, duration)...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err = cmd.Run(); err != nil {
+		return errors.Wrapf(err, "Running ffprobe: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	if stderr.Len() > 0 {
+		return errors.Wrapf(ErrInvalidReplacement, "Decoding errors at the end of file: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	// ffprobe does not report errors in this case, so it's not a proof of broken file
+	if strings.TrimSpace(stdout.String()) == "" {
+		return errors.Errorf("ffprobe returned no video frames for the last %.0f seconds of file", tailCheckSeconds)
+	}
+
+	return nil
+}
+
+// decodableTailArgs returns ffprobe arguments for decoding last tailCheckSeconds of video stream.
+// best_effort_timestamp_time is used, because pkt_pts_time was removed in FFmpeg 5
+func decodableTailArgs(file files.Filer, duration float64) []string {
+	start := math.Max(0, duration-tailCheckSeconds)
+
+	return []string{
+		"-v", "error",
+		"-select_streams", "v:0",
+		"-read_intervals", fmt.Sprintf("%.3f%%", start),
+		"-show_entries", "frame=best_effort_timestamp_time",
+		"-of", "csv=p=0",
+		file.FullPath(),
+	}
+}
+
+// replaceOriginal checks converted file, copies modification time of original file
+// and puts converted file to original path
+func (c *Converter) replaceOriginal(
+	task Task,
+	metadata ffmpegModels.Metadata,
+	original, replacement files.Filer,
+	modTime time.Time,
+) error {
+	replacementMetadata, err := minfo.New().GetMediaInfo(replacement)
+
+	if err != nil {
+		return errors.Wrap(err, "Getting converted file metadata")
+	}
+
+	if err = validateReplacement(task, metadata, replacementMetadata); err != nil {
+		return err
+	}
+
+	if err = checkDecodableTail(c.ctx, replacement, replacementMetadata.DurationSeconds()); err != nil {
+		return errors.Wrap(err, "Checking end of converted file")
+	}
+
+	if err = replacement.SetChTime(modTime); err != nil {
+		return errors.Wrap(err, "Setting converted file modification time")
+	}
+
+	return swapOriginal(original, replacement, task.Params.TrashPath)
+}
+
+// swapOriginal replaces original file with replacement using rename, so original path
+// always contains one of files. If trashPath is set, original file is kept there
+func swapOriginal(original, replacement files.Filer, trashPath string) error {
+	if trashPath != "" {
+		if err := keepInTrash(original, files.NewPath(trashPath)); err != nil {
+			return errors.Wrap(err, "Keeping original file in trash")
+		}
+	}
+
+	if err := replacement.Move(original.FullPath()); err != nil {
+		return errors.Wrap(err, "Replacing original file")
+	}
+
+	return nil
+}
+
+// keepInTrash links original file to trash directory. If trash is located on other device,
+// original file is copied. Original path is overwritten by replacement later
+func keepInTrash(original files.Filer, trash files.Pather) error {
+	if err := trash.Create(); err != nil {
+		return errors.Wrap(err, "Creating trash directory")
+	}
+
+	trashFile := trash.BuildFile(original.Name())
+
+	for i := 1; isOutputTaken(trashFile); i++ {
+		if i > maxTrashAttempts {
+			return errors.New("Searching free file name in trash")
+		}
+
+		trashFile = trash.BuildFile(original.Name()).NewWithSuffix(fmt.Sprintf("_%d", i))
+	}
+
+	if err := os.Link(original.FullPath(), trashFile.FullPath()); err == nil {
+		return nil
+	}
+
+	return copyFile(original, trashFile)
+}
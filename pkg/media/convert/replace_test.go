@@ -0,0 +1,149 @@
+package convert
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/wailorman/fftb/pkg/files"
+	ffmpegModels "github.com/wailorman/fftb/pkg/goffmpeg/models"
+)
+
+func newReplacementMetadata(duration string, codecTypes ...string) ffmpegModels.Metadata {
+	metadata := ffmpegModels.Metadata{
+		Format: ffmpegModels.Format{Duration: duration},
+	}
+
+	for i, codecType := range codecTypes {
+		metadata.Streams = append(metadata.Streams, ffmpegModels.Streams{Index: i, CodecType: codecType})
+	}
+
+	return metadata
+}
+
+func Test__validateReplacement(t *testing.T) {
+	type testCase struct {
+		name        string
+		params      Params
+		original    ffmpegModels.Metadata
+		replacement ffmpegModels.Metadata
+		wantErr     bool
+	}
+
+	cases := []testCase{
+		{
+			name:        "valid",
+			original:    newReplacementMetadata("60.0", "video", "audio"),
+			replacement: newReplacementMetadata("60.5", "video", "audio"),
+		},
+		{
+			name:        "duration differs",
+			original:    newReplacementMetadata("60.0", "video", "audio"),
+			replacement: newReplacementMetadata("30.0", "video", "audio"),
+			wantErr:     true,
+		},
+		{
+			name:        "relative tolerance for long video",
+			original:    newReplacementMetadata("3600.0", "video"),
+			replacement: newReplacementMetadata("3620.0", "video"),
+		},
+		{
+			name:        "no video",
+			original:    newReplacementMetadata("60.0", "video", "audio"),
+			replacement: newReplacementMetadata("60.0", "audio"),
+			wantErr:     true,
+		},
+		{
+			name:        "audio lost",
+			original:    newReplacementMetadata("60.0", "video", "audio"),
+			replacement: newReplacementMetadata("60.0", "video"),
+			wantErr:     true,
+		},
+		{
+			name:        "audio dropped intentionally",
+			params:      Params{DropAudio: true},
+			original:    newReplacementMetadata("60.0", "video", "audio"),
+			replacement: newReplacementMetadata("60.0", "video"),
+		},
+		{
+			name:        "streams lost",
+			params:      Params{KeepAllStreams: true},
+			original:    newReplacementMetadata("60.0", "video", "audio", "subtitle"),
+			replacement: newReplacementMetadata("60.0", "video", "audio"),
+			wantErr:     true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := validateReplacement(Task{Params: c.params}, c.original, c.replacement)
+
+			if c.wantErr {
+				assert.Equal(t, ErrInvalidReplacement, errors.Cause(err))
+			} else {
+				assert.Nil(t, err)
+			}
+		})
+	}
+}
+
+func Test__IsReplaceTempFile(t *testing.T) {
+	original := files.NewFile("/videos/clip.mp4")
+
+	assert.False(t, IsReplaceTempFile(original))
+	assert.True(t, IsReplaceTempFile(replaceTempFile(original)))
+	assert.Equal(t, "/videos/clip.fftb_tmp.mp4", replaceTempFile(original).FullPath())
+}
+
+func Test__swapOriginal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fftb_swap")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	originalPath := filepath.Join(dir, "clip.mp4")
+	trashPath := filepath.Join(dir, "trash")
+
+	for i, content := range []string{"first", "second"} {
+		original := files.NewFile(originalPath)
+		replacement := replaceTempFile(original)
+
+		assert.Nil(t, ioutil.WriteFile(originalPath, []byte("original"+content), 0644))
+		assert.Nil(t, ioutil.WriteFile(replacement.FullPath(), []byte("converted"+content), 0644))
+
+		assert.Nil(t, swapOriginal(original, replacement, trashPath))
+
+		got, _ := original.ReadAllContent()
+		assert.Equal(t, "converted"+content, got)
+		assert.False(t, replacement.IsExist())
+
+		trashFile := files.NewPath(trashPath).BuildFile("clip.mp4")
+
+		if i > 0 {
+			trashFile = trashFile.NewWithSuffix("_1")
+		}
+
+		got, _ = trashFile.ReadAllContent()
+		assert.Equal(t, "original"+content, got)
+	}
+}
+
+func Test__decodableTailArgs(t *testing.T) {
+	assert := assert.New(t)
+
+	args := decodableTailArgs(files.NewFile("/tmp/out.mp4"), 62.5)
+
+	assert.Equal([]string{
+		"-v", "error",
+		"-select_streams", "v:0",
+		"-read_intervals", "57.500%",
+		"-show_entries", "frame=best_effort_timestamp_time",
+		"-of", "csv=p=0",
+		"/tmp/out.mp4",
+	}, args)
+
+	// short files are decoded from the beginning
+	assert.Contains(decodableTailArgs(files.NewFile("/tmp/out.mp4"), 3), "0.000%")
+}
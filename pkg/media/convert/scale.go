@@ -2,6 +2,9 @@ package convert
 
 import (
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
 
 	ffmpegModels "github.com/wailorman/fftb/pkg/goffmpeg/models"
 	mediaUtils "github.com/wailorman/fftb/pkg/media/utils"
@@ -27,55 +30,147 @@ func newVideoScale(task Task, metadata ffmpegModels.Metadata) *videoScale {
 }
 
 func (pv *videoScale) configure(mediaFile *ffmpegModels.Mediafile) error {
-	if pv.task.Params.Scale == "" {
+	params := pv.task.Params
+
+	if params.Scale == "" && params.MaxWidth == 0 && params.MaxHeight == 0 {
 		return nil
 	}
 
-	var width, height int
 	origWidth, origHeight := getVideoResolution(pv.metadata)
 
 	if origWidth <= 4 || origHeight <= 4 {
 		return ErrResolutionNotSupportScaling
 	}
 
-	switch pv.task.Params.Scale {
+	width, height, err := calcScaledResolution(params.Scale, float64(origWidth), float64(origHeight))
+
+	if err != nil {
+		return err
+	}
+
+	width, height = capResolution(width, height, params.MaxWidth, params.MaxHeight)
+
+	// most of encoders require even frame dimensions
+	evenWidth, evenHeight := roundToEven(width), roundToEven(height)
+
+	if evenWidth == origWidth && evenHeight == origHeight {
+		return nil
+	}
+
+	scaleFilter := fmt.Sprintf(
+		"%s=%d:%d",
+		scaleFilterName(params.HWAccel),
+		evenWidth,
+		evenHeight,
+	)
+
+	// hardware accelerators can set frames uploading filter before scaling
+	if mediaFile.VideoFilter() != "" {
+		scaleFilter = mediaFile.VideoFilter() + "," + scaleFilter
+	}
+
+	mediaFile.SetVideoFilter(scaleFilter)
+
+	return nil
+}
+
+// calcScaledResolution supports scale values:
+// "1/2", "1/4" — fixed fraction of original resolution;
+// "1280x720", "1280:720" — exact resolution;
+// "-2:1080", "1280:-1" — one dimension, other is calculated with preserving aspect ratio;
+// "720p" — same as "-2:720"
+func calcScaledResolution(scale string, width, height float64) (float64, float64, error) {
+	switch scale {
+	case "":
+		return width, height, nil
 	case FixedHalfScaleType:
-		width = origWidth / 2
-		height = origHeight / 2
+		return width / 2, height / 2, nil
 	case FixedQuarterScaleType:
-		width = origWidth / 4
-		height = origHeight / 4
-	case "":
-		return nil
+		return width / 4, height / 4, nil
+	}
+
+	if strings.HasSuffix(scale, "p") {
+		targetHeight, err := strconv.Atoi(strings.TrimSuffix(scale, "p"))
+
+		if err != nil || targetHeight <= 0 {
+			return 0, 0, ErrUnsupportedScale
+		}
+
+		return width * float64(targetHeight) / height, float64(targetHeight), nil
+	}
+
+	parts := strings.FieldsFunc(scale, func(r rune) bool {
+		return r == 'x' || r == ':'
+	})
+
+	if len(parts) != 2 {
+		return 0, 0, ErrUnsupportedScale
+	}
+
+	targetWidth, wErr := strconv.Atoi(parts[0])
+	targetHeight, hErr := strconv.Atoi(parts[1])
+
+	if wErr != nil || hErr != nil {
+		return 0, 0, ErrUnsupportedScale
+	}
+
+	switch {
+	case targetWidth > 0 && targetHeight > 0:
+		return float64(targetWidth), float64(targetHeight), nil
+	case isAutoDimension(targetWidth) && targetHeight > 0:
+		return width * float64(targetHeight) / height, float64(targetHeight), nil
+	case targetWidth > 0 && isAutoDimension(targetHeight):
+		return float64(targetWidth), height * float64(targetWidth) / width, nil
 	default:
-		return ErrUnsupportedScale
+		return 0, 0, ErrUnsupportedScale
+	}
+}
+
+// isAutoDimension checks ffmpeg's scale filter values for dimension which should be calculated from aspect ratio
+func isAutoDimension(value int) bool {
+	return value == -1 || value == -2
+}
+
+// capResolution reduces resolution to fit max width & max height with preserving aspect ratio.
+// Zero max value means no limit
+func capResolution(width, height float64, maxWidth, maxHeight int) (float64, float64) {
+	if maxWidth > 0 && width > float64(maxWidth) {
+		height = height * float64(maxWidth) / width
+		width = float64(maxWidth)
 	}
 
-	if pv.task.Params.HWAccel == NvencHWAccelType {
-		mediaFile.SetVideoFilter(
-			fmt.Sprintf(
-				"scale_cuda=%d:%d",
-				width,
-				height,
-			),
-		)
-	} else {
-		mediaFile.SetVideoFilter(
-			fmt.Sprintf(
-				"scale=%d:%d",
-				width,
-				height,
-			),
-		)
+	if maxHeight > 0 && height > float64(maxHeight) {
+		width = width * float64(maxHeight) / height
+		height = float64(maxHeight)
 	}
 
-	return nil
+	return width, height
+}
+
+func roundToEven(value float64) int {
+	return int(math.Round(value/2)) * 2
+}
+
+// scaleFilterName returns scaling filter which works with frames in hardware accelerator's memory
+func scaleFilterName(hwAccel string) string {
+	switch hwAccel {
+	case NvencHWAccelType:
+		return "scale_cuda"
+	case VaapiHWAccelType:
+		return "scale_vaapi"
+	case QsvHWAccelType:
+		return "scale_qsv"
+	default:
+		return "scale"
+	}
 }
 
 func getVideoResolution(metadata ffmpegModels.Metadata) (width, height int) {
-	if !mediaUtils.IsVideo(metadata) {
+	stream, ok := mediaUtils.FindVideoStream(metadata)
+
+	if !ok {
 		return 0, 0
 	}
 
-	return metadata.Streams[0].Width, metadata.Streams[0].Height
+	return stream.Width, stream.Height
 }
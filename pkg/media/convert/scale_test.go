@@ -0,0 +1,55 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	ffmpegModels "github.com/wailorman/fftb/pkg/goffmpeg/models"
+)
+
+func Test__videoScale__configure(t *testing.T) {
+	assert := assert.New(t)
+
+	// audio stream goes first in some recordings
+	metadata := ffmpegModels.Metadata{
+		Streams: []ffmpegModels.Streams{
+			{Index: 0, CodecType: "audio", CodecName: "aac", BitRate: "128000"},
+			{Index: 1, CodecType: "video", CodecName: "h264", Width: 2560, Height: 1440, BitRate: "20000000"},
+		},
+	}
+
+	testTable := []struct {
+		params         Params
+		expectedFilter string
+		expectedErr    error
+	}{
+		{params: Params{}, expectedFilter: ""},
+		{params: Params{Scale: FixedHalfScaleType}, expectedFilter: "scale=1280:720"},
+		{params: Params{Scale: "1280x720"}, expectedFilter: "scale=1280:720"},
+		{params: Params{Scale: "-2:1080"}, expectedFilter: "scale=1920:1080"},
+		{params: Params{Scale: "1000:-1"}, expectedFilter: "scale=1000:562"},
+		{params: Params{Scale: "480p"}, expectedFilter: "scale=854:480"},
+		{params: Params{Scale: "1080p", HWAccel: NvencHWAccelType}, expectedFilter: "scale_cuda=1920:1080"},
+		{params: Params{MaxHeight: 1080}, expectedFilter: "scale=1920:1080"},
+		{params: Params{MaxWidth: 1366}, expectedFilter: "scale=1366:768"},
+		{params: Params{MaxHeight: 2160}, expectedFilter: ""},
+		{params: Params{Scale: "3840x2160", MaxHeight: 1440}, expectedFilter: ""},
+		{params: Params{Scale: "-2:-2"}, expectedErr: ErrUnsupportedScale},
+		{params: Params{Scale: "1/3"}, expectedErr: ErrUnsupportedScale},
+	}
+
+	for i, testItem := range testTable {
+		mediaFile := newTestMediaFile()
+
+		err := newVideoScale(Task{Params: testItem.params}, metadata).configure(mediaFile)
+
+		if testItem.expectedErr != nil {
+			assert.Equal(testItem.expectedErr, errors.Cause(err), i)
+			continue
+		}
+
+		assert.Nil(err, i)
+		assert.Equal(testItem.expectedFilter, mediaFile.VideoFilter(), i)
+	}
+}
@@ -0,0 +1,137 @@
+package convert
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/wailorman/fftb/pkg/files"
+)
+
+const (
+	// DeleteLargerOutputPolicy _
+	DeleteLargerOutputPolicy = "delete"
+	// KeepInputLargerOutputPolicy _
+	KeepInputLargerOutputPolicy = "keep-input"
+)
+
+// DefaultMaxSizeRatio is used when --keep-if-smaller is passed without ratio
+const DefaultMaxSizeRatio = 1.0
+
+// sizeCheck compares output file size with input file size
+// and gets rid of output which is not small enough
+type sizeCheck struct {
+	task Task
+}
+
+func newSizeCheck(task Task) *sizeCheck {
+	return &sizeCheck{task: task}
+}
+
+// validate checks larger output policy before conversion, so invalid params don't waste a full encode
+func (sc *sizeCheck) validate() error {
+	switch sc.task.Params.LargerOutputPolicy {
+	case "", DeleteLargerOutputPolicy, KeepInputLargerOutputPolicy:
+		return nil
+	default:
+		return errors.Wrapf(ErrUnsupportedLargerOutputPolicy, "Received `%s`", sc.task.Params.LargerOutputPolicy)
+	}
+}
+
+func (sc *sizeCheck) isActive() bool {
+	return sc.task.Params.KeepIfSmaller || sc.task.Params.MaxSizeRatio > 0
+}
+
+func (sc *sizeCheck) maxRatio() float64 {
+	if sc.task.Params.MaxSizeRatio > 0 {
+		return sc.task.Params.MaxSizeRatio
+	}
+
+	return DefaultMaxSizeRatio
+}
+
+// isAcceptable returns true if output size fits max size ratio
+func (sc *sizeCheck) isAcceptable(inSize, outSize int) bool {
+	if !sc.isActive() {
+		return true
+	}
+
+	return float64(outSize) <= float64(inSize)*sc.maxRatio()
+}
+
+// apply measures files & handles output which is too large according to policy.
+// Returns ErrTaskSkipped if output was deleted
+func (sc *sizeCheck) apply(inFile, outFile files.Filer, result *TaskResult) error {
+	inSize, err := inFile.Size()
+
+	if err != nil {
+		return errors.Wrap(err, "Getting input file size")
+	}
+
+	outSize, err := outFile.Size()
+
+	if err != nil {
+		return errors.Wrap(err, "Getting output file size")
+	}
+
+	result.InputSize = inSize
+	result.OutputSize = outSize
+
+	if sc.isAcceptable(inSize, outSize) {
+		return nil
+	}
+
+	reason := fmt.Sprintf(
+		"Output file is not small enough (input: %d bytes, output: %d bytes, max ratio: %g)",
+		inSize, outSize, sc.maxRatio(),
+	)
+
+	switch sc.task.Params.LargerOutputPolicy {
+	case "", DeleteLargerOutputPolicy:
+		if err = outFile.Remove(); err != nil {
+			return errors.Wrap(err, "Removing output file")
+		}
+
+		return errors.Wrap(ErrTaskSkipped, reason)
+
+	case KeepInputLargerOutputPolicy:
+		if err = copyFile(inFile, outFile); err != nil {
+			return errors.Wrap(err, "Replacing output file with input")
+		}
+
+		result.OutputSize = inSize
+		result.KeptInput = true
+
+		return nil
+
+	default:
+		return errors.Wrapf(ErrUnsupportedLargerOutputPolicy, "Received `%s`", sc.task.Params.LargerOutputPolicy)
+	}
+}
+
+func copyFile(src, dst files.Filer) error {
+	if err := dst.Create(); err != nil {
+		return errors.Wrap(err, "Creating destination file")
+	}
+
+	reader, err := src.ReadContent()
+
+	if err != nil {
+		return errors.Wrap(err, "Opening source file")
+	}
+
+	defer reader.Close()
+
+	writer, err := dst.WriteContent()
+
+	if err != nil {
+		return errors.Wrap(err, "Opening destination file")
+	}
+
+	if _, err = io.Copy(writer, reader); err != nil {
+		writer.Close()
+		return errors.Wrap(err, "Copying content")
+	}
+
+	return writer.Close()
+}
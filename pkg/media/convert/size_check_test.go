@@ -0,0 +1,96 @@
+package convert
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/wailorman/fftb/pkg/files"
+)
+
+func Test__sizeCheck__isAcceptable(t *testing.T) {
+	type testCase struct {
+		name    string
+		params  Params
+		inSize  int
+		outSize int
+		want    bool
+	}
+
+	cases := []testCase{
+		{name: "inactive", params: Params{}, inSize: 100, outSize: 200, want: true},
+		{name: "smaller", params: Params{KeepIfSmaller: true}, inSize: 100, outSize: 90, want: true},
+		{name: "equal", params: Params{KeepIfSmaller: true}, inSize: 100, outSize: 100, want: true},
+		{name: "larger", params: Params{KeepIfSmaller: true}, inSize: 100, outSize: 101, want: false},
+		{name: "ratio ok", params: Params{MaxSizeRatio: 0.9}, inSize: 100, outSize: 90, want: true},
+		{name: "ratio exceeded", params: Params{MaxSizeRatio: 0.9}, inSize: 100, outSize: 95, want: false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sc := newSizeCheck(Task{Params: c.params})
+			assert.Equal(t, c.want, sc.isAcceptable(c.inSize, c.outSize))
+		})
+	}
+}
+
+func Test__sizeCheck__apply(t *testing.T) {
+	type testCase struct {
+		name         string
+		params       Params
+		wantSkipped  bool
+		wantOutExist bool
+		wantSaved    int
+		wantKeptIn   bool
+	}
+
+	cases := []testCase{
+		{name: "inactive", params: Params{}, wantOutExist: true, wantSaved: -10},
+		{name: "delete", params: Params{KeepIfSmaller: true}, wantSkipped: true},
+		{
+			name:         "keep input",
+			params:       Params{KeepIfSmaller: true, LargerOutputPolicy: KeepInputLargerOutputPolicy},
+			wantOutExist: true,
+			wantKeptIn:   true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "fftb_size_check")
+			assert.Nil(t, err)
+			defer os.RemoveAll(dir)
+
+			inPath := filepath.Join(dir, "in.mp4")
+			outPath := filepath.Join(dir, "out.mp4")
+
+			assert.Nil(t, ioutil.WriteFile(inPath, []byte(strings.Repeat("i", 20)), 0644))
+			assert.Nil(t, ioutil.WriteFile(outPath, []byte(strings.Repeat("o", 30)), 0644))
+
+			result := TaskResult{}
+			err = newSizeCheck(Task{Params: c.params}).apply(files.NewFile(inPath), files.NewFile(outPath), &result)
+
+			if c.wantSkipped {
+				assert.Equal(t, ErrTaskSkipped, errors.Cause(err))
+			} else {
+				assert.Nil(t, err)
+			}
+
+			assert.Equal(t, c.wantOutExist, files.NewFile(outPath).IsExist())
+			assert.Equal(t, c.wantKeptIn, result.KeptInput)
+
+			if !c.wantSkipped {
+				assert.Equal(t, c.wantSaved, result.SavedBytes())
+			}
+
+			if c.wantKeptIn {
+				content, _ := files.NewFile(outPath).ReadAllContent()
+				assert.Equal(t, strings.Repeat("i", 20), content)
+			}
+		})
+	}
+}
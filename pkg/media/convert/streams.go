@@ -0,0 +1,152 @@
+package convert
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	ffmpegModels "github.com/wailorman/fftb/pkg/goffmpeg/models"
+)
+
+// DefaultMergedAudioCodec is used when merged audio track should be encoded but audio codec is not set
+const DefaultMergedAudioCodec = "aac"
+
+const mergedAudioLabel = "[aout]"
+
+type streamMapping struct {
+	task     Task
+	metadata ffmpegModels.Metadata
+}
+
+func newStreamMapping(task Task, metadata ffmpegModels.Metadata) *streamMapping {
+	return &streamMapping{
+		task:     task,
+		metadata: metadata,
+	}
+}
+
+func (sm *streamMapping) configure(mediaFile *ffmpegModels.Mediafile) error {
+	params := sm.task.Params
+
+	if !sm.isActive() {
+		// ffmpeg chooses one stream of each type by default
+		return nil
+	}
+
+	maps := []string{"0:v:0"}
+
+	if !params.DropAudio {
+		audioTracks, err := sm.selectAudioTracks()
+
+		if err != nil {
+			return err
+		}
+
+		if params.MergeAudio && len(audioTracks) > 1 {
+			mediaFile.SetComplexFilter(buildAmixFilter(audioTracks))
+			maps = append(maps, mergedAudioLabel)
+
+			// filtered audio can't be stream copied
+			if mediaFile.AudioCodec() == CopyAudioCodecType {
+				mediaFile.SetAudioCodec(DefaultMergedAudioCodec)
+			}
+		} else {
+			for _, track := range audioTracks {
+				maps = append(maps, fmt.Sprintf("0:a:%d", track))
+			}
+		}
+	}
+
+	if params.KeepAllStreams || params.CopySubtitles {
+		maps = append(maps, "0:s?")
+		mediaFile.SetSubtitleCodec("copy")
+	}
+
+	if params.KeepAllStreams || params.CopyData {
+		maps = append(maps, "0:d?")
+		mediaFile.SetDataCodec("copy")
+	}
+
+	mediaFile.SetStreamMaps(maps)
+
+	return nil
+}
+
+// selectAudioTracks returns indexes of audio tracks (among audio streams only).
+// Selector is an audio track index or a language code. All tracks are selected if there are no selectors
+func (sm *streamMapping) selectAudioTracks() ([]int, error) {
+	audioStreams := sm.audioStreams()
+	tracks := make([]int, 0)
+
+	if len(sm.task.Params.AudioTracks) == 0 {
+		for i := range audioStreams {
+			tracks = append(tracks, i)
+		}
+
+		return tracks, nil
+	}
+
+	selected := make(map[int]bool)
+
+	for _, selector := range sm.task.Params.AudioTracks {
+		matched := make([]int, 0)
+
+		if index, err := strconv.Atoi(selector); err == nil {
+			if index >= 0 && index < len(audioStreams) {
+				matched = append(matched, index)
+			}
+		} else {
+			for i, stream := range audioStreams {
+				if strings.EqualFold(stream.Tags.Language, selector) {
+					matched = append(matched, i)
+				}
+			}
+		}
+
+		if len(matched) == 0 {
+			return nil, ErrAudioTrackNotFound
+		}
+
+		for _, track := range matched {
+			if !selected[track] {
+				selected[track] = true
+				tracks = append(tracks, track)
+			}
+		}
+	}
+
+	return tracks, nil
+}
+
+// isActive returns false if streams should be mapped by ffmpeg's default rules
+func (sm *streamMapping) isActive() bool {
+	params := sm.task.Params
+
+	return params.KeepAllStreams ||
+		len(params.AudioTracks) > 0 ||
+		params.MergeAudio ||
+		params.CopySubtitles ||
+		params.CopyData
+}
+
+func (sm *streamMapping) audioStreams() []ffmpegModels.Streams {
+	audioStreams := make([]ffmpegModels.Streams, 0)
+
+	for _, stream := range sm.metadata.Streams {
+		if stream.CodecType == "audio" {
+			audioStreams = append(audioStreams, stream)
+		}
+	}
+
+	return audioStreams
+}
+
+func buildAmixFilter(tracks []int) string {
+	inputs := ""
+
+	for _, track := range tracks {
+		inputs += fmt.Sprintf("[0:a:%d]", track)
+	}
+
+	return fmt.Sprintf("%samix=inputs=%d:duration=longest%s", inputs, len(tracks), mergedAudioLabel)
+}
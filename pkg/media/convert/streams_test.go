@@ -0,0 +1,82 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	ffmpegModels "github.com/wailorman/fftb/pkg/goffmpeg/models"
+)
+
+func Test__streamMapping__configure(t *testing.T) {
+	assert := assert.New(t)
+
+	metadata := newTestVideoMetadata("h264")
+	metadata.Streams = append(
+		metadata.Streams,
+		ffmpegModels.Streams{Index: 1, CodecType: "audio", CodecName: "aac", Tags: ffmpegModels.StreamTags{Language: "eng"}},
+		ffmpegModels.Streams{Index: 2, CodecType: "audio", CodecName: "pcm_s16le", Tags: ffmpegModels.StreamTags{Language: "rus"}},
+		ffmpegModels.Streams{Index: 3, CodecType: "subtitle", CodecName: "mov_text"},
+	)
+
+	testTable := []struct {
+		params          Params
+		expectedCommand []string
+		expectedErr     error
+	}{
+		{
+			params:          Params{},
+			expectedCommand: []string{"-i", "in.mp4", "-c:a", "copy", "out.mp4"},
+		},
+		{
+			params: Params{KeepAllStreams: true},
+			expectedCommand: []string{
+				"-i", "in.mp4", "-map", "0:v:0", "-map", "0:a:0", "-map", "0:a:1", "-map", "0:s?", "-map", "0:d?",
+				"-c:a", "copy", "-c:s", "copy", "-c:d", "copy", "out.mp4",
+			},
+		},
+		{
+			params:          Params{AudioTracks: []string{"RUS"}},
+			expectedCommand: []string{"-i", "in.mp4", "-map", "0:v:0", "-map", "0:a:1", "-c:a", "copy", "out.mp4"},
+		},
+		{
+			params: Params{AudioTracks: []string{"1", "0"}, MergeAudio: true},
+			expectedCommand: []string{
+				"-i", "in.mp4", "-filter_complex", "[0:a:1][0:a:0]amix=inputs=2:duration=longest[aout]",
+				"-map", "0:v:0", "-map", "[aout]", "-c:a", "aac", "out.mp4",
+			},
+		},
+		{
+			params: Params{MergeAudio: true, AudioCodec: "opus", CopySubtitles: true},
+			expectedCommand: []string{
+				"-i", "in.mp4", "-filter_complex", "[0:a:0][0:a:1]amix=inputs=2:duration=longest[aout]",
+				"-map", "0:v:0", "-map", "[aout]", "-map", "0:s?", "-c:a", "libopus", "-c:s", "copy", "out.mp4",
+			},
+		},
+		{
+			params:      Params{AudioTracks: []string{"2"}},
+			expectedErr: ErrAudioTrackNotFound,
+		},
+		{
+			params:      Params{AudioTracks: []string{"jpn"}},
+			expectedErr: ErrAudioTrackNotFound,
+		},
+	}
+
+	for i, testItem := range testTable {
+		task := Task{Params: testItem.params}
+		mediaFile := newTestMediaFile()
+
+		assert.Nil(newAudioTranscoding(task).configure(mediaFile), i)
+
+		err := newStreamMapping(task, metadata).configure(mediaFile)
+
+		if testItem.expectedErr != nil {
+			assert.Equal(testItem.expectedErr, errors.Cause(err), i)
+			continue
+		}
+
+		assert.Nil(err, i)
+		assert.Equal(testItem.expectedCommand, mediaFile.ToStrCommand(), i)
+	}
+}
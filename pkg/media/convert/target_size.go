@@ -0,0 +1,151 @@
+package convert
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	ffmpegModels "github.com/wailorman/fftb/pkg/goffmpeg/models"
+)
+
+// containerOverheadRatio is a part of target size reserved for container headers & indexes
+const containerOverheadRatio = 0.02
+
+// defaultEncodedAudioBitRate is used for size estimation when audio bitrate is unknown
+const defaultEncodedAudioBitRate = 128000
+
+// minTargetSizeVideoBitRate is a minimum video bitrate which makes sense for encoding
+const minTargetSizeVideoBitRate = 100000
+
+// applyTargetSize calculates video bitrate which fits output file to target size.
+// Returns task as is if target size is not set
+func applyTargetSize(task Task, metadata ffmpegModels.Metadata) (Task, error) {
+	if task.Params.TargetSize == "" {
+		return task, nil
+	}
+
+	targetSize, err := ParseSize(task.Params.TargetSize)
+
+	if err != nil {
+		return task, err
+	}
+
+	duration := metadata.DurationSeconds()
+
+	if duration <= 0 {
+		return task, ErrUnknownDuration
+	}
+
+	totalBitRate := float64(targetSize) * 8 * (1 - containerOverheadRatio) / duration
+	videoBitRate := int64(totalBitRate) - estimateAudioBitRate(task, metadata)
+
+	if videoBitRate < minTargetSizeVideoBitRate {
+		return task, ErrTargetSizeTooSmall
+	}
+
+	task.Params.VideoBitRate = fmt.Sprintf("%dk", videoBitRate/1000)
+	task.Params.VideoQuality = 0
+	task.Params.TwoPass = true
+
+	return task, nil
+}
+
+// estimateAudioBitRate returns summary bitrate of all output audio tracks
+func estimateAudioBitRate(task Task, metadata ffmpegModels.Metadata) int64 {
+	params := task.Params
+
+	if params.DropAudio {
+		return 0
+	}
+
+	mapping := newStreamMapping(task, metadata)
+	tracks, err := mapping.selectAudioTracks()
+
+	if err != nil || len(tracks) == 0 {
+		return 0
+	}
+
+	if !mapping.isActive() {
+		// ffmpeg keeps only one audio track by default
+		tracks = tracks[:1]
+	}
+
+	merged := params.MergeAudio && len(tracks) > 1
+	encoded := merged || (params.AudioCodec != "" && params.AudioCodec != CopyAudioCodecType)
+
+	if encoded {
+		trackBitRate, err := parseBitRate(params.AudioBitRate)
+
+		if err != nil || trackBitRate == 0 {
+			trackBitRate = defaultEncodedAudioBitRate
+		}
+
+		if merged {
+			return trackBitRate
+		}
+
+		return trackBitRate * int64(len(tracks))
+	}
+
+	audioStreams := mapping.audioStreams()
+	var total int64
+
+	for _, track := range tracks {
+		trackBitRate, err := strconv.ParseInt(audioStreams[track].BitRate, 10, 64)
+
+		if err != nil || trackBitRate == 0 {
+			trackBitRate = defaultEncodedAudioBitRate
+		}
+
+		total += trackBitRate
+	}
+
+	return total
+}
+
+// ParseSize parses file size in bytes. Supported suffixes: K, M, G (powers of 1000), optional "B".
+// Examples: 50M, 8MB, 700K
+func ParseSize(value string) (int64, error) {
+	number, multiplier := splitUnitSuffix(strings.TrimSuffix(strings.ToUpper(value), "B"))
+
+	size, err := strconv.ParseFloat(number, 64)
+
+	if err != nil || size <= 0 {
+		return 0, ErrInvalidSize
+	}
+
+	return int64(size * multiplier), nil
+}
+
+// parseBitRate parses bitrate in ffmpeg format (i.e. 192k, 25M)
+func parseBitRate(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	number, multiplier := splitUnitSuffix(strings.ToUpper(value))
+
+	bitRate, err := strconv.ParseFloat(number, 64)
+
+	if err != nil {
+		return 0, ErrInvalidSize
+	}
+
+	return int64(bitRate * multiplier), nil
+}
+
+func splitUnitSuffix(value string) (string, float64) {
+	multipliers := map[string]float64{
+		"K": 1000,
+		"M": 1000 * 1000,
+		"G": 1000 * 1000 * 1000,
+	}
+
+	for suffix, multiplier := range multipliers {
+		if strings.HasSuffix(value, suffix) {
+			return strings.TrimSuffix(value, suffix), multiplier
+		}
+	}
+
+	return value, 1
+}
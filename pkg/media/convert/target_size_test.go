@@ -0,0 +1,140 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	ffmpegModels "github.com/wailorman/fftb/pkg/goffmpeg/models"
+)
+
+func Test__applyTargetSize(t *testing.T) {
+	assert := assert.New(t)
+
+	metadata := newTestVideoMetadata("h264")
+	metadata.Format.Duration = "100.000000"
+	metadata.Streams = append(
+		metadata.Streams,
+		ffmpegModels.Streams{Index: 1, CodecType: "audio", CodecName: "aac", BitRate: "128000"},
+		ffmpegModels.Streams{Index: 2, CodecType: "audio", CodecName: "aac", BitRate: "64000"},
+	)
+
+	testTable := []struct {
+		params               Params
+		expectedVideoBitRate string
+		expectedErr          error
+	}{
+		{
+			params:               Params{VideoBitRate: "10M"},
+			expectedVideoBitRate: "10M",
+		},
+		{
+			params:               Params{TargetSize: "50M", VideoQuality: 30},
+			expectedVideoBitRate: "3792k",
+		},
+		{
+			params:               Params{TargetSize: "50MB", DropAudio: true},
+			expectedVideoBitRate: "3920k",
+		},
+		{
+			params:               Params{TargetSize: "50M", KeepAllStreams: true},
+			expectedVideoBitRate: "3728k",
+		},
+		{
+			params:               Params{TargetSize: "50M", MergeAudio: true, AudioBitRate: "96k"},
+			expectedVideoBitRate: "3824k",
+		},
+		{
+			params:      Params{TargetSize: "1M"},
+			expectedErr: ErrTargetSizeTooSmall,
+		},
+		{
+			params:      Params{TargetSize: "fifty"},
+			expectedErr: ErrInvalidSize,
+		},
+	}
+
+	for i, testItem := range testTable {
+		task, err := applyTargetSize(Task{Params: testItem.params}, metadata)
+
+		if testItem.expectedErr != nil {
+			assert.Equal(testItem.expectedErr, errors.Cause(err), i)
+			continue
+		}
+
+		assert.Nil(err, i)
+		assert.Equal(testItem.expectedVideoBitRate, task.Params.VideoBitRate, i)
+
+		if testItem.params.TargetSize != "" {
+			assert.Equal(0, task.Params.VideoQuality, i)
+			assert.True(task.Params.TwoPass, i)
+		}
+	}
+}
+
+func Test__twoPass__command(t *testing.T) {
+	assert := assert.New(t)
+
+	testTable := []struct {
+		params             Params
+		expectedTwoPass    bool
+		expectedFirstPass  []string
+		expectedSecondPass []string
+	}{
+		{
+			params:          Params{VideoCodec: H264CodecType, VideoBitRate: "3792k", TwoPass: true},
+			expectedTwoPass: true,
+			expectedFirstPass: []string{
+				"-vsync", "0", "-i", "in.mp4", "-hide_banner", "-c:v", "libx264", "-b:v", "3792k", "-an",
+				"-max_muxing_queue_size", "102400", "-pass", "1", "-passlogfile", "/tmp/passlog", "-f", "null", "out.mp4",
+			},
+			expectedSecondPass: []string{
+				"-vsync", "0", "-i", "in.mp4", "-hide_banner", "-c:v", "libx264", "-b:v", "3792k", "-c:a", "copy",
+				"-max_muxing_queue_size", "102400", "-pass", "2", "-passlogfile", "/tmp/passlog", "out.mp4",
+			},
+		},
+		{
+			params:          Params{VideoCodec: HevcCodecType, VideoBitRate: "3792k", TwoPass: true},
+			expectedTwoPass: true,
+			expectedFirstPass: []string{
+				"-vsync", "0", "-i", "in.mp4", "-hide_banner", "-c:v", "libx265", "-b:v", "3792k", "-an",
+				"-x265-params", "pass=1:stats=/tmp/passlog.log", "-max_muxing_queue_size", "102400",
+				"-f", "null", "-tag:v", "hvc1", "out.mp4",
+			},
+			expectedSecondPass: []string{
+				"-vsync", "0", "-i", "in.mp4", "-hide_banner", "-c:v", "libx265", "-b:v", "3792k", "-c:a", "copy",
+				"-x265-params", "pass=2:stats=/tmp/passlog.log", "-max_muxing_queue_size", "102400",
+				"-tag:v", "hvc1", "out.mp4",
+			},
+		},
+		{
+			params:          Params{VideoCodec: HevcCodecType, HWAccel: NvencHWAccelType, VideoBitRate: "3792k", TwoPass: true},
+			expectedTwoPass: false,
+		},
+		{
+			params:          Params{VideoCodec: H264CodecType, VideoQuality: 25, TwoPass: true},
+			expectedTwoPass: false,
+		},
+	}
+
+	for i, testItem := range testTable {
+		task := Task{Params: testItem.params}
+		metadata := newTestVideoMetadata("h264")
+
+		secondPass := newTestMediaFile()
+		assert.Nil(configureMediaFile(task, metadata, secondPass), i)
+		assert.Equal(testItem.expectedTwoPass, isTwoPass(task, secondPass), i)
+
+		if !testItem.expectedTwoPass {
+			continue
+		}
+
+		firstPass := newTestMediaFile()
+		assert.Nil(configureVideo(task, metadata, firstPass), i)
+		configureFirstPass(firstPass, "/tmp/passlog")
+		setEncodingPass(secondPass, 2, "/tmp/passlog")
+
+		assert.Equal(testItem.expectedFirstPass, firstPass.ToStrCommand(), i)
+		assert.Equal(testItem.expectedSecondPass, secondPass.ToStrCommand(), i)
+	}
+}
@@ -0,0 +1,61 @@
+package convert
+
+import (
+	ffmpegModels "github.com/wailorman/fftb/pkg/goffmpeg/models"
+	"github.com/wailorman/fftb/pkg/media/ff"
+)
+
+// twoPassEncoders is a list of encoders which support two-pass encoding
+var twoPassEncoders = map[string]bool{
+	"libx264":         true,
+	"libx265":         true,
+	AomAv1EncoderType: true,
+}
+
+// isTwoPass returns true if task should be encoded in two passes.
+// Encoders without two-pass support use single pass with the same bitrate
+func isTwoPass(task Task, mediaFile *ffmpegModels.Mediafile) bool {
+	if !task.Params.TwoPass || task.Params.VideoBitRate == "" || task.Params.VideoQuality > 0 {
+		return false
+	}
+
+	return twoPassEncoders[mediaFile.VideoCodec()]
+}
+
+// configureFirstPass prepares first pass which only analyzes video & writes statistics to pass log
+func configureFirstPass(mediaFile *ffmpegModels.Mediafile, passLogFile string) {
+	setEncodingPass(mediaFile, 1, passLogFile)
+	mediaFile.SetSkipAudio(true)
+	mediaFile.SetOutputFormat("null")
+}
+
+func setEncodingPass(mediaFile *ffmpegModels.Mediafile, pass int, passLogFile string) {
+	if mediaFile.VideoCodec() != "libx265" {
+		mediaFile.SetPass(pass)
+		mediaFile.SetPassLogFile(passLogFile)
+		return
+	}
+
+	x265Params := ffmpegModels.Libx265Params{}
+
+	if mediaFile.Libx265Params() != nil {
+		x265Params = *mediaFile.Libx265Params()
+	}
+
+	x265Params.Pass = pass
+	x265Params.Stats = passLogFile + ".log"
+
+	mediaFile.SetLibx265Params(&x265Params)
+}
+
+// passProgress reports progress of multi-pass encoding as a single 0-100% value
+type passProgress struct {
+	ff.Progressable
+	pass   int
+	passes int
+}
+
+// Progress _
+func (p *passProgress) Progress() float64 {
+	return (float64(p.pass-1)*100 + p.Progressable.Progress()) / float64(p.passes)
+}
@@ -0,0 +1,74 @@
+package convert
+
+import (
+	ffmpegModels "github.com/wailorman/fftb/pkg/goffmpeg/models"
+	mediaUtils "github.com/wailorman/fftb/pkg/media/utils"
+)
+
+// DefaultVaapiDevice _
+const DefaultVaapiDevice = "/dev/dri/renderD128"
+
+// vaapiDecodableCodecs is a list of input codecs which can be decoded by VAAPI
+var vaapiDecodableCodecs = map[string]bool{
+	"h264":       true,
+	"hevc":       true,
+	"vp9":        true,
+	"av1":        true,
+	"mpeg2video": true,
+}
+
+type vaapiHWAccel struct {
+	task     Task
+	metadata ffmpegModels.Metadata
+}
+
+func (hw *vaapiHWAccel) configure(mediaFile *ffmpegModels.Mediafile) error {
+	if !mediaUtils.IsVideo(hw.metadata) {
+		return ErrFileIsNotVideo
+	}
+
+	device := hw.task.Params.HWDevice
+
+	if device == "" {
+		device = DefaultVaapiDevice
+	}
+
+	if vaapiDecodableCodecs[mediaUtils.GetVideoCodec(hw.metadata)] {
+		// decoded frames stay in GPU memory
+		mediaFile.SetHardwareAcceleration("vaapi")
+		mediaFile.SetHardwareAccelerationDevice(device)
+		mediaFile.SetHardwareAccelerationOutputFormat("vaapi")
+	} else {
+		// frames decoded by CPU should be uploaded to GPU
+		mediaFile.SetInitHardwareDevice("vaapi=hw:" + device)
+		mediaFile.SetFilterHardwareDevice("hw")
+		mediaFile.SetVideoFilter("format=nv12,hwupload")
+	}
+
+	// VAAPI encoders does not support presets
+	mediaFile.SetPreset("")
+	resetCPUEncoderOptions(mediaFile)
+
+	if hw.task.Params.VideoQuality > 0 {
+		mediaFile.SetVideoBitRate("")
+		mediaFile.SetRateControlMode("CQP")
+		mediaFile.SetConstantQuantization(hw.task.Params.VideoQuality)
+	}
+
+	switch hw.task.Params.VideoCodec {
+	case HevcCodecType:
+		mediaFile.SetVideoCodec("hevc_vaapi")
+	case H264CodecType:
+		mediaFile.SetVideoCodec("h264_vaapi")
+	case Av1CodecType:
+		mediaFile.SetVideoCodec("av1_vaapi")
+	default:
+		return ErrCodecIsNotSupportedByEncoder
+	}
+
+	return nil
+}
+
+func (hw *vaapiHWAccel) getType() string {
+	return VaapiHWAccelType
+}
@@ -0,0 +1,72 @@
+package convert
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/wailorman/fftb/pkg/files"
+	"github.com/wailorman/fftb/pkg/media/minfo"
+	mediaUtils "github.com/wailorman/fftb/pkg/media/utils"
+)
+
+// BuildWatchTask builds conversion task for file which appeared in watched directory (task.InPath).
+// Output file is placed like in recursive mode without flattening.
+// Task ID is a relative path of input file, so journal records are matched after restart.
+// Returns ErrTaskSkipped if file should not be converted: it's not a video, journal or conversion output
+func BuildWatchTask(task RecursiveTask, file files.Filer, infoGetter minfo.Getter) (Task, error) {
+	relPath, err := filepath.Rel(task.InPath.FullPath(), file.FullPath())
+
+	if err != nil {
+		return Task{}, errors.Wrapf(err, "Getting relative path of `%s`", file.FullPath())
+	}
+
+	if isWatchOutput(task, file) {
+		return Task{}, errors.Wrap(ErrTaskSkipped, "File is a conversion output")
+	}
+
+	metadata, err := infoGetter.GetMediaInfo(file)
+
+	if err != nil || !mediaUtils.IsVideo(metadata) {
+		return Task{}, errors.Wrap(ErrTaskSkipped, "File is not a video")
+	}
+
+	task.Flatten = false
+
+	outFiles, err := buildRecursiveOutFiles(task, []files.Filer{file})
+
+	if err != nil {
+		return Task{}, errors.Wrap(err, "Building output file path")
+	}
+
+	return Task{
+		ID:      filepath.ToSlash(relPath),
+		InFile:  file.FullPath(),
+		OutFile: outFiles[0].FullPath(),
+		Params:  task.Params,
+	}, nil
+}
+
+// isWatchOutput returns true if file is created by conversion: journal, temporary file,
+// original file kept in trash or converted file when output path is inside of watched path
+func isWatchOutput(task RecursiveTask, file files.Filer) bool {
+	if IsReplaceTempFile(file) || file.Name() == DefaultJournalFileName {
+		return true
+	}
+
+	if task.Params.TrashPath != "" && isInsidePath(files.NewPath(task.Params.TrashPath), file) {
+		return true
+	}
+
+	if task.Params.ReplaceOriginal || task.OutPath.Equal(task.InPath) {
+		return false
+	}
+
+	return isInsidePath(task.OutPath, file)
+}
+
+func isInsidePath(path files.Pather, file files.Filer) bool {
+	relPath, err := filepath.Rel(path.FullPath(), file.FullPath())
+
+	return err == nil && relPath != ".." && !strings.HasPrefix(relPath, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,62 @@
+package convert_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/wailorman/fftb/pkg/files"
+	"github.com/wailorman/fftb/pkg/media/convert"
+)
+
+func Test__BuildWatchTask(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpDir, err := ioutil.TempDir("", "fftb_watch_task_test")
+	assert.Nil(err)
+	defer os.RemoveAll(tmpDir)
+
+	inPath := files.NewPath(tmpDir)
+	outPath := inPath.BuildSubpath("converted")
+
+	recursiveTask := convert.RecursiveTask{
+		InPath:  inPath,
+		OutPath: outPath,
+		Flatten: true,
+	}
+
+	testTable := []struct {
+		name            string
+		expectedID      string
+		expectedOutFile string
+		skipped         bool
+	}{
+		{name: "a.mp4", expectedID: "a.mp4", expectedOutFile: "converted/a.mp4"},
+		{name: "game/a.mp4", expectedID: "game/a.mp4", expectedOutFile: "converted/game/a.mp4"},
+		{name: "converted/a.mp4", skipped: true},
+		{name: "a" + convert.ReplaceTempSuffix + ".mp4", skipped: true},
+		{name: "converted/" + convert.DefaultJournalFileName, skipped: true},
+	}
+
+	for _, testItem := range testTable {
+		task, err := convert.BuildWatchTask(recursiveTask, inPath.BuildFile(testItem.name), &videoInfoGetterStub{})
+
+		if testItem.skipped {
+			assert.Equal(convert.ErrTaskSkipped, errors.Cause(err), testItem.name)
+			continue
+		}
+
+		assert.Nil(err, testItem.name)
+		assert.Equal(testItem.expectedID, task.ID)
+		assert.Equal(filepath.Join(tmpDir, filepath.FromSlash(testItem.expectedOutFile)), task.OutFile)
+	}
+
+	// originals kept in trash should not be converted again
+	recursiveTask.Params.TrashPath = inPath.BuildSubpath("trash").FullPath()
+
+	_, err = convert.BuildWatchTask(recursiveTask, inPath.BuildFile("trash/a.mp4"), &videoInfoGetterStub{})
+	assert.Equal(convert.ErrTaskSkipped, errors.Cause(err))
+}
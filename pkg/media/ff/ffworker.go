@@ -24,6 +24,13 @@ var ErrAlreadyInitialized = errors.New("already initialized")
 // ErrProcessTimeout happened when ffmpeg does not send any messages more than ProcessTimeout value
 var ErrProcessTimeout = errors.New("ffmpeg process timeout")
 
+// ErrProcessFailed happened when ffmpeg exits with error
+var ErrProcessFailed = errors.New("ffmpeg process failed")
+
+// StopTimeout is maximum time ffmpeg allowed to finish writing output after stop request.
+// Once this timeout reached, ffmpeg process will be killed
+var StopTimeout = time.Duration(10 * time.Second)
+
 // ProcessTimeout is maximum time ffmpeg allowed to not send any messages.
 // Once this timeout reached, ErrProcessTimeout will happened
 var ProcessTimeout = time.Duration(30 * time.Second)
@@ -37,16 +44,31 @@ type Instance struct {
 	inFile      files.Filer
 	outFile     files.Filer
 	transcoder  *goffmpegTranscoder.Transcoder
+	inMetadata  *goffmpegModels.Metadata
+
+	processTimeout time.Duration
 }
 
 // New just initializing & configuring instance before start up
 func New(ctx context.Context) *Instance {
 	return &Instance{
-		ctx: ctx,
-		wg:  chwg.New(),
+		ctx:            ctx,
+		wg:             chwg.New(),
+		processTimeout: ProcessTimeout,
 	}
 }
 
+// SetProcessTimeout overrides ProcessTimeout value for this instance. Should be called before Start()
+func (c *Instance) SetProcessTimeout(timeout time.Duration) {
+	c.processTimeout = timeout
+}
+
+// SetInputMetadata passes already known input file metadata to transcoder,
+// so it will not be requested from ffprobe again. Should be called before Init()
+func (c *Instance) SetInputMetadata(metadata goffmpegModels.Metadata) {
+	c.inMetadata = &metadata
+}
+
 // Init receives input & output file objects and initializing transcoder.
 // Returns an error if transcoder can't initialize
 func (c *Instance) Init(inFile, outFile files.Filer) error {
@@ -58,6 +80,10 @@ func (c *Instance) Init(inFile, outFile files.Filer) error {
 	c.outFile = outFile
 	c.transcoder = goffmpegTranscoder.New(c.ctx)
 
+	if c.inMetadata != nil {
+		c.transcoder.SetInputMetadata(*c.inMetadata)
+	}
+
 	err := c.transcoder.Initialize(inFile.FullPath(), outFile.FullPath())
 
 	if err != nil {
@@ -107,19 +133,19 @@ func (c *Instance) Start() (
 
 		_progress := c.transcoder.Output()
 
-		t := time.NewTimer(ProcessTimeout)
+		t := time.NewTimer(c.processTimeout)
 		defer t.Stop()
 
 		for {
 			select {
 			case <-c.ctx.Done():
-				c.transcoder.Stop()
+				c.stop(done, _progress)
 				failures <- c.ctx.Err()
 				return
 
 			case progressMessage, ok := <-_progress:
 				if ok && progressMessage.FramesProcessed != "" {
-					t.Reset(ProcessTimeout)
+					t.Reset(c.processTimeout)
 
 					progress <- &Progress{
 						framesProcessed: progressMessage.FramesProcessed,
@@ -134,7 +160,7 @@ func (c *Instance) Start() (
 
 			case err := <-done:
 				if err != nil {
-					failures <- err
+					failures <- errors.Wrap(ErrProcessFailed, err.Error())
 				}
 
 				return
@@ -150,6 +176,32 @@ func (c *Instance) Start() (
 	return progress, failures
 }
 
+// stop asks ffmpeg to finish writing output & waits until process exits
+func (c *Instance) stop(done <-chan error, progress <-chan goffmpegModels.Progress) {
+	c.transcoder.Stop()
+
+	t := time.NewTimer(StopTimeout)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+
+		// progress messages should be read, otherwise ffmpeg can stuck on writing to stderr
+		case _, ok := <-progress:
+			if !ok {
+				progress = nil
+			}
+
+		case <-t.C:
+			c.transcoder.Kill()
+			<-done
+			return
+		}
+	}
+}
+
 // Closed returns channel with finished signal
 func (c *Instance) Closed() <-chan struct{} {
 	return c.wg.Closed()
@@ -0,0 +1,145 @@
+package minfo
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/wailorman/fftb/pkg/files"
+	ffmpegModels "github.com/wailorman/fftb/pkg/goffmpeg/models"
+)
+
+// CachedInstance is a Getter decorator which remembers media info of files.
+// Cache entry is invalidated when file size or modification time changes
+type CachedInstance struct {
+	getter    Getter
+	cacheFile files.Filer
+	mutex     sync.Mutex
+	entries   map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	Size     int64                 `json:"size"`
+	ModTime  time.Time             `json:"mod_time"`
+	Metadata ffmpegModels.Metadata `json:"metadata"`
+}
+
+// NewCached returns in-memory caching Getter
+func NewCached(getter Getter) *CachedInstance {
+	return &CachedInstance{
+		getter:  getter,
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+// NewCachedWithFile returns caching Getter which loads entries from cacheFile.
+// Call Save() to persist received media info
+func NewCachedWithFile(getter Getter, cacheFile files.Filer) (*CachedInstance, error) {
+	ci := NewCached(getter)
+	ci.cacheFile = cacheFile
+
+	if !cacheFile.IsExist() {
+		return ci, nil
+	}
+
+	content, err := cacheFile.ReadAllContent()
+
+	if err != nil {
+		return nil, errors.Wrap(err, "Reading media info cache file")
+	}
+
+	if err = json.Unmarshal([]byte(content), &ci.entries); err != nil {
+		return nil, errors.Wrap(err, "Parsing media info cache file")
+	}
+
+	if ci.entries == nil {
+		ci.entries = make(map[string]cacheEntry)
+	}
+
+	return ci, nil
+}
+
+// GetMediaInfo _
+func (ci *CachedInstance) GetMediaInfo(file files.Filer) (ffmpegModels.Metadata, error) {
+	info, err := os.Stat(file.FullPath())
+
+	if err != nil {
+		return ffmpegModels.Metadata{}, errors.Wrap(err, "Getting file info")
+	}
+
+	ci.mutex.Lock()
+	entry, ok := ci.entries[file.FullPath()]
+	ci.mutex.Unlock()
+
+	if ok && entry.Size == info.Size() && entry.ModTime.Equal(info.ModTime()) {
+		return entry.Metadata, nil
+	}
+
+	metadata, err := ci.getter.GetMediaInfo(file)
+
+	if err != nil {
+		return metadata, err
+	}
+
+	ci.mutex.Lock()
+	ci.entries[file.FullPath()] = cacheEntry{
+		Size:     info.Size(),
+		ModTime:  info.ModTime(),
+		Metadata: metadata,
+	}
+	ci.mutex.Unlock()
+
+	return metadata, nil
+}
+
+// GetFramesSummary _
+func (ci *CachedInstance) GetFramesSummary(file files.Filer) (FramesSummary, error) {
+	return ci.getter.GetFramesSummary(file)
+}
+
+// GetFramesList _
+func (ci *CachedInstance) GetFramesList(file files.Filer) (chan bool, chan ffmpegModels.Framer, chan error) {
+	return ci.getter.GetFramesList(file)
+}
+
+// Save writes cache entries to cache file. Does nothing for in-memory cache
+func (ci *CachedInstance) Save() error {
+	if ci.cacheFile == nil {
+		return nil
+	}
+
+	ci.mutex.Lock()
+	content, err := json.Marshal(ci.entries)
+	ci.mutex.Unlock()
+
+	if err != nil {
+		return errors.Wrap(err, "Marshaling media info cache")
+	}
+
+	tmpFile := ci.cacheFile.NewWithSuffix("_tmp")
+
+	if err = tmpFile.Create(); err != nil {
+		return errors.Wrap(err, "Creating temp media info cache file")
+	}
+
+	writer, err := tmpFile.WriteContent()
+
+	if err != nil {
+		return errors.Wrap(err, "Building temp media info cache file writer")
+	}
+
+	_, err = writer.Write(content)
+	writer.Close()
+
+	if err != nil {
+		return errors.Wrap(err, "Writing temp media info cache file")
+	}
+
+	if err = tmpFile.Move(ci.cacheFile.FullPath()); err != nil {
+		return errors.Wrap(err, "Moving temp media info cache file")
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package minfo
+
+import (
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wailorman/fftb/pkg/files"
+	ffmpegModels "github.com/wailorman/fftb/pkg/goffmpeg/models"
+)
+
+type countingGetterStub struct {
+	mutex sync.Mutex
+	calls int
+}
+
+func (ig *countingGetterStub) GetMediaInfo(file files.Filer) (ffmpegModels.Metadata, error) {
+	ig.mutex.Lock()
+	defer ig.mutex.Unlock()
+
+	ig.calls++
+
+	return ffmpegModels.Metadata{
+		Streams: []ffmpegModels.Streams{{CodecName: "h264", BitRate: "1000"}},
+		Format:  ffmpegModels.Format{Filename: file.FullPath()},
+	}, nil
+}
+
+func (ig *countingGetterStub) GetFramesSummary(file files.Filer) (FramesSummary, error) {
+	return FramesSummary{}, nil
+}
+
+func (ig *countingGetterStub) GetFramesList(file files.Filer) (chan bool, chan ffmpegModels.Framer, chan error) {
+	return nil, nil, nil
+}
+
+func Test__CachedInstance(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpDir, err := ioutil.TempDir("", "fftb_minfo_cache_test")
+	assert.Nil(err)
+	defer os.RemoveAll(tmpDir)
+
+	root := files.NewPath(tmpDir)
+	videoFile := root.BuildFile("video.mp4")
+	cacheFile := root.BuildFile("cache.json")
+	assert.Nil(videoFile.Create())
+
+	stub := &countingGetterStub{}
+	cached, err := NewCachedWithFile(stub, cacheFile)
+	assert.Nil(err)
+
+	metadata, err := cached.GetMediaInfo(videoFile)
+	assert.Nil(err)
+	assert.Equal("h264", metadata.Streams[0].CodecName)
+
+	_, err = cached.GetMediaInfo(videoFile)
+	assert.Nil(err)
+	assert.Equal(1, stub.calls, "second call should be served from cache")
+
+	assert.Nil(cached.Save())
+
+	reloadedStub := &countingGetterStub{}
+	reloaded, err := NewCachedWithFile(reloadedStub, cacheFile)
+	assert.Nil(err)
+
+	metadata, err = reloaded.GetMediaInfo(videoFile)
+	assert.Nil(err)
+	assert.Equal(videoFile.FullPath(), metadata.Format.Filename)
+	assert.Equal(0, reloadedStub.calls, "media info should be loaded from cache file")
+
+	assert.Nil(videoFile.SetChTime(time.Now().Add(-time.Hour)))
+
+	_, err = reloaded.GetMediaInfo(videoFile)
+	assert.Nil(err)
+	assert.Equal(1, reloadedStub.calls, "modified file should be probed again")
+}
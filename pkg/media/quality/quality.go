@@ -0,0 +1,193 @@
+package quality
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/wailorman/fftb/pkg/files"
+	"github.com/wailorman/fftb/pkg/goffmpeg/ffmpeg"
+)
+
+const (
+	// VmafMetric _
+	VmafMetric = "vmaf"
+	// SsimMetric _
+	SsimMetric = "ssim"
+	// PsnrMetric _
+	PsnrMetric = "psnr"
+)
+
+// maxPSNR replaces infinite PSNR of identical videos
+const maxPSNR = 100
+
+// outputTailLines is a number of ffmpeg output lines included to error message
+const outputTailLines = 5
+
+// ErrUnsupportedMetric _
+var ErrUnsupportedMetric = errors.New("Unsupported quality metric")
+
+// ErrNoMetrics _
+var ErrNoMetrics = errors.New("No quality metrics")
+
+// ErrScoreNotFound _
+var ErrScoreNotFound = errors.New("Quality score not found in ffmpeg output")
+
+var metricFilters = map[string]string{
+	VmafMetric: "libvmaf",
+	SsimMetric: "ssim",
+	PsnrMetric: "psnr",
+}
+
+var scorePatterns = map[string]*regexp.Regexp{
+	VmafMetric: regexp.MustCompile(`VMAF score[:=] ?([\d.]+)`),
+	SsimMetric: regexp.MustCompile(`SSIM .*All:([\d.]+)`),
+	PsnrMetric: regexp.MustCompile(`PSNR .*average:([\d.]+|inf)`),
+}
+
+// Scores _
+type Scores struct {
+	VMAF float64 `json:"vmaf,omitempty" yaml:"vmaf,omitempty"`
+	SSIM float64 `json:"ssim,omitempty" yaml:"ssim,omitempty"`
+	PSNR float64 `json:"psnr,omitempty" yaml:"psnr,omitempty"`
+}
+
+// Measurer _
+type Measurer interface {
+	Measure(reference, distorted files.Filer) (Scores, error)
+}
+
+// Instance _
+type Instance struct {
+	ctx     context.Context
+	metrics []string
+}
+
+// DefaultMetrics returns all supported metrics
+func DefaultMetrics() []string {
+	return []string{VmafMetric, SsimMetric, PsnrMetric}
+}
+
+// New returns quality measurer. Metrics are compared in one ffmpeg run
+func New(ctx context.Context, metrics []string) (*Instance, error) {
+	if len(metrics) == 0 {
+		return nil, ErrNoMetrics
+	}
+
+	for _, metric := range metrics {
+		if _, ok := metricFilters[metric]; !ok {
+			return nil, errors.Wrapf(ErrUnsupportedMetric, "Metric `%s`", metric)
+		}
+	}
+
+	return &Instance{
+		ctx:     ctx,
+		metrics: metrics,
+	}, nil
+}
+
+// Measure compares distorted (converted) video with reference (original) one
+func (i *Instance) Measure(reference, distorted files.Filer) (Scores, error) {
+	cfg, err := ffmpeg.Configure(i.ctx)
+
+	if err != nil {
+		return Scores{}, errors.Wrap(err, "Configuring ffmpeg")
+	}
+
+	cmd := exec.CommandContext(i.ctx, cfg.FfmpegBin, buildCommand(reference, distorted, i.metrics)...)
+	output, err := cmd.CombinedOutput()
+
+	if err != nil {
+		return Scores{}, errors.Wrapf(err, "Running ffmpeg: %s", tail(string(output), outputTailLines))
+	}
+
+	return parseScores(string(output), i.metrics)
+}
+
+// buildCommand returns ffmpeg arguments. Distorted video is scaled to reference resolution
+// because metrics can be calculated only for frames with same size
+func buildCommand(reference, distorted files.Filer, metrics []string) []string {
+	distortedLabels := ""
+	referenceLabels := ""
+	comparisons := make([]string, 0, len(metrics))
+
+	for i, metric := range metrics {
+		distortedLabel := fmt.Sprintf("[d%d]", i)
+		referenceLabel := fmt.Sprintf("[r%d]", i)
+
+		distortedLabels += distortedLabel
+		referenceLabels += referenceLabel
+
+		comparisons = append(comparisons, distortedLabel+referenceLabel+metricFilters[metric])
+	}
+
+	graph := []string{
+		"[0:v][1:v]scale2ref=flags=bicubic[dist][ref]",
+		fmt.Sprintf("[dist]setpts=PTS-STARTPTS,split=%d%s", len(metrics), distortedLabels),
+		fmt.Sprintf("[ref]setpts=PTS-STARTPTS,split=%d%s", len(metrics), referenceLabels),
+	}
+
+	graph = append(graph, comparisons...)
+
+	return []string{
+		"-hide_banner",
+		"-nostats",
+		"-i", distorted.FullPath(),
+		"-i", reference.FullPath(),
+		"-lavfi", strings.Join(graph, ";"),
+		"-f", "null",
+		"-",
+	}
+}
+
+func parseScores(output string, metrics []string) (Scores, error) {
+	scores := Scores{}
+
+	for _, metric := range metrics {
+		matches := scorePatterns[metric].FindAllStringSubmatch(output, -1)
+
+		if len(matches) == 0 {
+			return scores, errors.Wrapf(ErrScoreNotFound, "Metric `%s`", metric)
+		}
+
+		// summary line is printed last
+		value := matches[len(matches)-1][1]
+		var score float64
+
+		if value == "inf" {
+			score = maxPSNR
+		} else {
+			var err error
+			score, err = strconv.ParseFloat(value, 64)
+
+			if err != nil {
+				return scores, errors.Wrapf(err, "Parsing `%s` score", metric)
+			}
+		}
+
+		switch metric {
+		case VmafMetric:
+			scores.VMAF = score
+		case SsimMetric:
+			scores.SSIM = score
+		case PsnrMetric:
+			scores.PSNR = score
+		}
+	}
+
+	return scores, nil
+}
+
+func tail(output string, count int) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+
+	if len(lines) > count {
+		lines = lines[len(lines)-count:]
+	}
+
+	return strings.Join(lines, "\n")
+}
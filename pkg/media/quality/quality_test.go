@@ -0,0 +1,87 @@
+package quality
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/wailorman/fftb/pkg/files"
+)
+
+func Test__buildCommand(t *testing.T) {
+	assert := assert.New(t)
+
+	command := buildCommand(files.NewFile("/in/ref.mp4"), files.NewFile("/out/dist.mp4"), []string{VmafMetric, PsnrMetric})
+
+	assert.Equal([]string{
+		"-hide_banner",
+		"-nostats",
+		"-i", "/out/dist.mp4",
+		"-i", "/in/ref.mp4",
+		"-lavfi", "[0:v][1:v]scale2ref=flags=bicubic[dist][ref];" +
+			"[dist]setpts=PTS-STARTPTS,split=2[d0][d1];" +
+			"[ref]setpts=PTS-STARTPTS,split=2[r0][r1];" +
+			"[d0][r0]libvmaf;[d1][r1]psnr",
+		"-f", "null",
+		"-",
+	}, command)
+}
+
+func Test__parseScores(t *testing.T) {
+	assert := assert.New(t)
+
+	output := "[Parsed_libvmaf_6 @ 0x7f8] VMAF score: 94.518209\n" +
+		"[Parsed_ssim_7 @ 0x7f9] SSIM Y:0.987045 (18.875494) U:0.991208 (20.559302) V:0.990669 (20.301184) All:0.988477 (19.383424)\n" +
+		"[Parsed_psnr_8 @ 0x7fa] PSNR y:41.390404 u:45.987617 v:46.055378 average:42.532451 min:36.115862 max:48.908107\n"
+
+	testTable := []struct {
+		output         string
+		metrics        []string
+		expectedScores Scores
+		expectedErr    error
+	}{
+		{
+			output:         output,
+			metrics:        DefaultMetrics(),
+			expectedScores: Scores{VMAF: 94.518209, SSIM: 0.988477, PSNR: 42.532451},
+		},
+		{
+			output:         output,
+			metrics:        []string{SsimMetric},
+			expectedScores: Scores{SSIM: 0.988477},
+		},
+		{
+			output:         "[Parsed_psnr_4 @ 0x7fa] PSNR y:inf u:inf v:inf average:inf min:inf max:inf\n",
+			metrics:        []string{PsnrMetric},
+			expectedScores: Scores{PSNR: maxPSNR},
+		},
+		{
+			output:      "[AVFilterGraph @ 0x7f8] No such filter: 'libvmaf'\n",
+			metrics:     []string{VmafMetric},
+			expectedErr: ErrScoreNotFound,
+		},
+	}
+
+	for i, testItem := range testTable {
+		scores, err := parseScores(testItem.output, testItem.metrics)
+
+		if testItem.expectedErr != nil {
+			assert.Equal(testItem.expectedErr, errors.Cause(err), i)
+			continue
+		}
+
+		assert.Nil(err, i)
+		assert.Equal(testItem.expectedScores, scores, i)
+	}
+}
+
+func Test__New(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := New(context.Background(), []string{VmafMetric, "butteraugli"})
+	assert.Equal(ErrUnsupportedMetric, errors.Cause(err))
+
+	_, err = New(context.Background(), nil)
+	assert.Equal(ErrNoMetrics, errors.Cause(err))
+}
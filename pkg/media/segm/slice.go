@@ -2,6 +2,7 @@ package segm
 
 import (
 	"context"
+	"strconv"
 
 	"github.com/pkg/errors"
 	"github.com/wailorman/fftb/pkg/chwg"
@@ -23,6 +24,8 @@ type SliceOperation struct {
 	ffworker       *ff.Instance
 	keepTimestamps bool
 	segmentSec     int
+	startSec       int
+	durationSec    int
 	initialized    bool
 	started        bool
 }
@@ -33,6 +36,9 @@ type SliceRequest struct {
 	OutPath        files.Pather
 	KeepTimestamps bool
 	SegmentSec     int
+	// StartSec & DurationSec limit sliced range of input file. Whole file is sliced by default
+	StartSec    int
+	DurationSec int
 }
 
 // NewSliceOperation _
@@ -59,6 +65,8 @@ func (so *SliceOperation) Init(req SliceRequest) error {
 	so.outPath = req.OutPath
 	so.keepTimestamps = req.KeepTimestamps
 	so.segmentSec = req.SegmentSec
+	so.startSec = req.StartSec
+	so.durationSec = req.DurationSec
 
 	so.tmpPath, err = createTmpSubdir(so.outPath)
 
@@ -84,6 +92,14 @@ func (so *SliceOperation) Init(req SliceRequest) error {
 	mediaFile.SetSegmentTime(so.segmentSec)
 	mediaFile.SetResetTimestamps(!so.keepTimestamps)
 
+	if so.startSec > 0 {
+		mediaFile.SetSeekTimeInput(strconv.Itoa(so.startSec))
+	}
+
+	if so.durationSec > 0 {
+		mediaFile.SetDuration(strconv.Itoa(so.durationSec))
+	}
+
 	so.initialized = true
 
 	return nil
@@ -2,7 +2,11 @@ package utils
 
 import (
 	"io"
+	"math"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/pkg/errors"
 	ffmpegModels "github.com/wailorman/fftb/pkg/goffmpeg/models"
@@ -11,18 +15,46 @@ import (
 	"github.com/wailorman/fftb/pkg/files"
 )
 
-// FilterVideos _
-func FilterVideos(allFiles []files.Filer, infoGetter minfo.Getter) []files.Filer {
-	videoFiles := make([]files.Filer, 0)
+// variableFrameRateTolerance is a maximum difference (in FPS) between average and base frame rates
+// of constant frame rate video
+const variableFrameRateTolerance = 0.01
 
-	for _, file := range allFiles {
-		mediaInfo, err := infoGetter.GetMediaInfo(file)
+// FilterVideos returns only video files. Files are probed by `parallelism` workers,
+// original order is preserved
+func FilterVideos(allFiles []files.Filer, infoGetter minfo.Getter, parallelism int) []files.Filer {
+	if parallelism < 1 {
+		parallelism = 1
+	}
 
-		if err != nil {
-			continue
-		}
+	isVideo := make([]bool, len(allFiles))
+	indexes := make(chan int)
+	wg := &sync.WaitGroup{}
+
+	for w := 0; w < parallelism; w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range indexes {
+				mediaInfo, err := infoGetter.GetMediaInfo(allFiles[i])
+
+				isVideo[i] = err == nil && IsVideo(mediaInfo)
+			}
+		}()
+	}
+
+	for i := range allFiles {
+		indexes <- i
+	}
+
+	close(indexes)
+	wg.Wait()
 
-		if IsVideo(mediaInfo) {
+	videoFiles := make([]files.Filer, 0)
+
+	for i, file := range allFiles {
+		if isVideo[i] {
 			videoFiles = append(videoFiles, file)
 		}
 	}
@@ -43,6 +75,68 @@ func IsVideo(metadata ffmpegModels.Metadata) bool {
 	return true
 }
 
+// FindVideoStream returns first video stream of the file. Streams[0] is not always a video stream
+func FindVideoStream(metadata ffmpegModels.Metadata) (ffmpegModels.Streams, bool) {
+	for _, stream := range metadata.Streams {
+		if stream.CodecType == "video" {
+			return stream, true
+		}
+	}
+
+	return ffmpegModels.Streams{}, false
+}
+
+// ParseFrameRate parses ffprobe's frame rate value like "30000/1001"
+func ParseFrameRate(value string) (float64, error) {
+	parts := strings.Split(value, "/")
+
+	numerator, err := strconv.ParseFloat(parts[0], 64)
+
+	if err != nil {
+		return 0, errors.Wrap(err, "Parsing frame rate numerator")
+	}
+
+	if len(parts) == 1 {
+		return numerator, nil
+	}
+
+	denominator, err := strconv.ParseFloat(parts[1], 64)
+
+	if err != nil {
+		return 0, errors.Wrap(err, "Parsing frame rate denominator")
+	}
+
+	if denominator == 0 {
+		return 0, nil
+	}
+
+	return numerator / denominator, nil
+}
+
+// IsVariableFrameRate returns true if average frame rate of video stream
+// differs from its real base frame rate
+func IsVariableFrameRate(metadata ffmpegModels.Metadata) bool {
+	stream, ok := FindVideoStream(metadata)
+
+	if !ok {
+		return false
+	}
+
+	avgFrameRate, err := ParseFrameRate(stream.AvgFrameRate)
+
+	if err != nil || avgFrameRate == 0 {
+		return false
+	}
+
+	baseFrameRate, err := ParseFrameRate(stream.RFrameRrate)
+
+	if err != nil || baseFrameRate == 0 {
+		return false
+	}
+
+	return math.Abs(avgFrameRate-baseFrameRate) > variableFrameRateTolerance
+}
+
 // GetVideoCodec _
 func GetVideoCodec(metadata ffmpegModels.Metadata) string {
 	if !IsVideo(metadata) {
@@ -0,0 +1,20 @@
+//go:build !windows
+// +build !windows
+
+package watch
+
+import (
+	"os"
+	"syscall"
+)
+
+// isLockError returns true if file is busy (i.e. executable which is running)
+func isLockError(err error) bool {
+	pathErr, ok := err.(*os.PathError)
+
+	if !ok {
+		return false
+	}
+
+	return pathErr.Err == syscall.EBUSY || pathErr.Err == syscall.ETXTBSY
+}
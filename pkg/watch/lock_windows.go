@@ -0,0 +1,22 @@
+package watch
+
+import (
+	"os"
+	"syscall"
+)
+
+const (
+	errorSharingViolation syscall.Errno = 32
+	errorLockViolation    syscall.Errno = 33
+)
+
+// isLockError returns true if file is opened by other process without sharing access
+func isLockError(err error) bool {
+	pathErr, ok := err.(*os.PathError)
+
+	if !ok {
+		return false
+	}
+
+	return pathErr.Err == errorSharingViolation || pathErr.Err == errorLockViolation
+}
@@ -0,0 +1,178 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/wailorman/fftb/pkg/files"
+)
+
+const (
+	// DefaultPollInterval _
+	DefaultPollInterval = 5 * time.Second
+	// DefaultStableDuration _
+	DefaultStableDuration = 30 * time.Second
+)
+
+// Watcher polls directory recursively & sends files which are completely written:
+// their size was not changed during StableDuration and they are not locked by other process.
+// Each file is sent once, but it will be sent again if its size is changed later
+type Watcher struct {
+	PollInterval   time.Duration
+	StableDuration time.Duration
+
+	path     files.Pather
+	filter   files.FilesFilter
+	states   map[string]*fileState
+	now      func() time.Time
+	isLocked func(path string) (bool, error)
+}
+
+type fileState struct {
+	size     int
+	changeAt time.Time
+	sent     bool
+}
+
+// New _
+func New(path files.Pather, filter files.FilesFilter) *Watcher {
+	return &Watcher{
+		PollInterval:   DefaultPollInterval,
+		StableDuration: DefaultStableDuration,
+
+		path:     path,
+		filter:   filter,
+		states:   make(map[string]*fileState),
+		now:      time.Now,
+		isLocked: isLocked,
+	}
+}
+
+// Watch starts polling until context is cancelled. Both channels are closed after that
+func (w *Watcher) Watch(ctx context.Context) (stableFiles chan files.Filer, failures chan error) {
+	stableFiles = make(chan files.Filer)
+	failures = make(chan error)
+
+	go func() {
+		defer close(stableFiles)
+		defer close(failures)
+
+		ticker := time.NewTicker(w.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			files, err := w.poll()
+
+			if err != nil {
+				select {
+				case failures <- err:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			for _, file := range files {
+				select {
+				case stableFiles <- file:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return stableFiles, failures
+}
+
+// poll updates files states & returns files which became stable since previous poll
+func (w *Watcher) poll() ([]files.Filer, error) {
+	allFiles, err := w.path.FilesWithFilter(w.filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	now := w.now()
+	stableFiles := make([]files.Filer, 0)
+	existingPaths := make(map[string]bool)
+	var lockErr error
+
+	for _, file := range allFiles {
+		size, err := file.Size()
+
+		// file can be removed while polling
+		if err != nil {
+			continue
+		}
+
+		path := file.FullPath()
+		existingPaths[path] = true
+		state, ok := w.states[path]
+
+		if !ok || state.size != size {
+			w.states[path] = &fileState{size: size, changeAt: now}
+			continue
+		}
+
+		if state.sent || now.Sub(state.changeAt) < w.StableDuration {
+			continue
+		}
+
+		locked, err := w.isLocked(path)
+
+		if err != nil {
+			// file is not sent until lock check succeeds, other files are still processed
+			if lockErr == nil {
+				lockErr = errors.Wrapf(err, "Checking lock of `%s`", path)
+			}
+
+			continue
+		}
+
+		if locked {
+			continue
+		}
+
+		state.sent = true
+		stableFiles = append(stableFiles, file)
+	}
+
+	for path := range w.states {
+		if !existingPaths[path] {
+			delete(w.states, path)
+		}
+	}
+
+	return stableFiles, lockErr
+}
+
+// isLocked returns true if file is opened by other process without shared write access.
+// Windows applications usually hold recording files this way (i.e. Nvidia ShadowPlay),
+// other systems do not lock files, so size check is the only criteria there.
+// Open errors which are not caused by lock are returned
+func isLocked(path string) (bool, error) {
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+
+	if err == nil {
+		file.Close()
+		return false, nil
+	}
+
+	if os.IsPermission(err) || os.IsNotExist(err) {
+		return false, nil
+	}
+
+	if isLockError(err) {
+		return true, nil
+	}
+
+	return false, err
+}
@@ -0,0 +1,72 @@
+package watch
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/wailorman/fftb/pkg/files"
+)
+
+func Test__poll(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpDir, err := ioutil.TempDir("", "fftb_watch_test")
+	assert.Nil(err)
+	defer os.RemoveAll(tmpDir)
+
+	path := files.NewPath(tmpDir)
+	file := path.BuildFile("record.mp4")
+	assert.Nil(file.Create())
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	locked := false
+	var lockErr error
+
+	watcher := New(path, files.FilesFilter{})
+	watcher.StableDuration = 10 * time.Second
+	watcher.now = func() time.Time { return now }
+	watcher.isLocked = func(path string) (bool, error) { return locked, lockErr }
+
+	pollNames := func() []string {
+		stableFiles, err := watcher.poll()
+		assert.Nil(err)
+
+		names := make([]string, 0)
+
+		for _, file := range stableFiles {
+			names = append(names, file.Name())
+		}
+
+		return names
+	}
+
+	assert.Empty(pollNames(), "new file is not stable yet")
+
+	now = now.Add(5 * time.Second)
+	assert.Nil(ioutil.WriteFile(file.FullPath(), []byte("data"), 0644))
+	assert.Empty(pollNames(), "file is still written")
+
+	now = now.Add(10 * time.Second)
+	locked = true
+	assert.Empty(pollNames(), "file is locked by other process")
+
+	locked = false
+	lockErr = errors.New("input/output error")
+	stableFiles, err := watcher.poll()
+	assert.NotNil(err, "lock check error is returned")
+	assert.Empty(stableFiles, "file is not sent if lock check failed")
+
+	lockErr = nil
+	assert.Equal([]string{"record.mp4"}, pollNames())
+	assert.Empty(pollNames(), "file is sent only once")
+
+	assert.Nil(ioutil.WriteFile(file.FullPath(), []byte("more data"), 0644))
+	assert.Empty(pollNames(), "changed file should become stable again")
+
+	now = now.Add(10 * time.Second)
+	assert.Equal([]string{"record.mp4"}, pollNames())
+}